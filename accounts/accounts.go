@@ -0,0 +1,231 @@
+package accounts
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"raindrop-mcp/api"
+	"raindrop-mcp/auth"
+
+	sdkauth "github.com/modelcontextprotocol/go-sdk/auth"
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+)
+
+// Resolver opens an API client for the named profile. Unless interactive is
+// set it must fail instead of starting a login that waits for the user, such
+// as a browser OAuth flow.
+type Resolver func(profile string, interactive bool) (*api.Client, error)
+
+// UserResolver opens the account of an authenticated HTTP user.
+// It is set in multi-user mode, where every session acts as its own user.
+type UserResolver func(info *sdkauth.TokenInfo) (*Account, error)
+
+// Account is a Raindrop account a session acts on
+type Account struct {
+	Profile string
+	Client  *api.Client
+
+	labelMu       sync.Mutex
+	label         string
+	labelSet      bool
+	labelFetching bool
+	labelRetryAt  time.Time
+}
+
+// labelRetryDelay is how long Label waits before looking the user up again
+// after a failure
+const labelRetryDelay = 5 * time.Minute
+
+// Label describes the account as "profile (Full Name <email>)". The user is
+// looked up by one caller at a time, outside the lock; other callers, and all
+// callers for a while after a failed lookup, get just the profile name.
+func (a *Account) Label(ctx context.Context) string {
+	a.labelMu.Lock()
+	if a.labelSet || a.labelFetching || time.Now().Before(a.labelRetryAt) {
+		defer a.labelMu.Unlock()
+		if a.labelSet {
+			return a.label
+		}
+		return a.Profile
+	}
+	a.labelFetching = true
+	a.labelMu.Unlock()
+
+	user, err := a.Client.GetUser(ctx)
+
+	a.labelMu.Lock()
+	defer a.labelMu.Unlock()
+	a.labelFetching = false
+	if err != nil {
+		a.labelRetryAt = time.Now().Add(labelRetryDelay)
+		return a.Profile
+	}
+	switch {
+	case user.FullName != "" && user.Email != "":
+		a.label = fmt.Sprintf("%s (%s <%s>)", a.Profile, user.FullName, user.Email)
+	case user.Email != "":
+		a.label = fmt.Sprintf("%s (%s)", a.Profile, user.Email)
+	default:
+		a.label = a.Profile
+	}
+	a.labelSet = true
+	return a.label
+}
+
+// Manager tracks which account each MCP session is using
+type Manager struct {
+	resolve        Resolver
+	defaultProfile string
+
+	users UserResolver
+
+	mu       sync.Mutex
+	accounts map[string]*Account
+	active   map[*mcp.ServerSession]string
+	sessions map[*mcp.ServerSession]*Account
+}
+
+// NewManager creates a manager whose sessions start on defaultProfile
+func NewManager(defaultProfile string, resolve Resolver) *Manager {
+	if defaultProfile == "" {
+		defaultProfile = auth.DefaultProfile
+	}
+	return &Manager{
+		resolve:        resolve,
+		defaultProfile: defaultProfile,
+		accounts:       make(map[string]*Account),
+		active:         make(map[*mcp.ServerSession]string),
+		sessions:       make(map[*mcp.ServerSession]*Account),
+	}
+}
+
+// SetUserResolver switches the manager to multi-user mode: each session gets
+// an isolated account for the user its bearer token belongs to, and profiles
+// are not available
+func (m *Manager) SetUserResolver(users UserResolver) {
+	m.users = users
+}
+
+// MultiUser reports whether accounts come from authenticated users
+func (m *Manager) MultiUser() bool {
+	return m.users != nil
+}
+
+// ForRequest returns the account a request acts on
+func (m *Manager) ForRequest(req mcp.Request) (*Account, error) {
+	ss, _ := req.GetSession().(*mcp.ServerSession)
+	if m.users == nil {
+		return m.ForSession(ss)
+	}
+
+	extra := req.GetExtra()
+	if extra == nil || extra.TokenInfo == nil {
+		return nil, errors.New("request is not authenticated")
+	}
+
+	m.mu.Lock()
+	account, ok := m.sessions[ss]
+	m.mu.Unlock()
+	if ok {
+		return account, nil
+	}
+
+	account, err := m.users(extra.TokenInfo)
+	if err != nil {
+		return nil, err
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if existing, ok := m.sessions[ss]; ok {
+		return existing, nil
+	}
+	m.sessions[ss] = account
+	return account, nil
+}
+
+// DefaultProfile returns the profile new sessions start on
+func (m *Manager) DefaultProfile() string {
+	return m.defaultProfile
+}
+
+// Account returns the account for a profile, opening it on first use
+func (m *Manager) Account(profile string) (*Account, error) {
+	return m.open(profile, true)
+}
+
+// open returns the account for a profile, resolving it on first use
+func (m *Manager) open(profile string, interactive bool) (*Account, error) {
+	m.mu.Lock()
+	account, ok := m.accounts[profile]
+	m.mu.Unlock()
+	if ok {
+		return account, nil
+	}
+
+	// Resolve outside the lock: it may run an interactive OAuth flow
+	client, err := m.resolve(profile, interactive)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open profile %q: %w", profile, err)
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if account, ok := m.accounts[profile]; ok {
+		return account, nil
+	}
+	account = &Account{Profile: profile, Client: client}
+	m.accounts[profile] = account
+	return account, nil
+}
+
+// ActiveProfile returns the profile selected for a session
+func (m *Manager) ActiveProfile(ss *mcp.ServerSession) string {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if profile, ok := m.active[ss]; ok {
+		return profile
+	}
+	return m.defaultProfile
+}
+
+// ForSession returns the account the session is currently using
+func (m *Manager) ForSession(ss *mcp.ServerSession) (*Account, error) {
+	return m.Account(m.ActiveProfile(ss))
+}
+
+// Switch makes the session use another profile for the rest of its lifetime.
+// It runs inside a tool call, so a profile that is not logged in is an error
+// rather than a reason to start a login.
+func (m *Manager) Switch(ss *mcp.ServerSession, profile string) (*Account, error) {
+	if err := auth.ValidateProfileName(profile); err != nil {
+		return nil, err
+	}
+
+	account, err := m.open(profile, false)
+	if err != nil {
+		return nil, err
+	}
+
+	m.mu.Lock()
+	m.active[ss] = profile
+	m.mu.Unlock()
+
+	return account, nil
+}
+
+// Forget drops the state of a closed session
+func (m *Manager) Forget(ss *mcp.ServerSession) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	delete(m.active, ss)
+	delete(m.sessions, ss)
+}
+
+// Profiles lists all configured profiles
+func (m *Manager) Profiles() ([]string, error) {
+	return auth.ListProfiles()
+}
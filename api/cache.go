@@ -0,0 +1,63 @@
+package api
+
+import (
+	"sync"
+	"time"
+)
+
+// responseCache keeps GET response bodies for a fixed time
+type responseCache struct {
+	ttl time.Duration
+
+	mu      sync.Mutex
+	entries map[string]cacheEntry
+}
+
+type cacheEntry struct {
+	body    []byte
+	expires time.Time
+}
+
+func newResponseCache(ttl time.Duration) *responseCache {
+	return &responseCache{
+		ttl:     ttl,
+		entries: make(map[string]cacheEntry),
+	}
+}
+
+// get returns a cached body if it has not expired
+func (c *responseCache) get(endpoint string) ([]byte, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	entry, ok := c.entries[endpoint]
+	if !ok {
+		return nil, false
+	}
+	if time.Now().After(entry.expires) {
+		delete(c.entries, endpoint)
+		return nil, false
+	}
+	return entry.body, true
+}
+
+// put stores a body for the cache TTL
+func (c *responseCache) put(endpoint string, body []byte) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.entries[endpoint] = cacheEntry{body: body, expires: time.Now().Add(c.ttl)}
+}
+
+// remove drops the entry for endpoint
+func (c *responseCache) remove(endpoint string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	delete(c.entries, endpoint)
+}
+
+// clear drops every entry
+func (c *responseCache) clear() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	clear(c.entries)
+}
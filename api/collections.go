@@ -1,14 +1,16 @@
 package api
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
+	"net/url"
 
 	"raindrop-mcp/types"
 )
 
 // CreateCollection creates a new collection
-func (c *Client) CreateCollection(title string, parentID int, isPublic bool) (*types.Collection, error) {
+func (c *Client) CreateCollection(ctx context.Context, title string, parentID int, isPublic bool) (*types.Collection, error) {
 	reqBody := types.CreateCollectionRequest{
 		Title:  title,
 		Public: isPublic,
@@ -16,8 +18,13 @@ func (c *Client) CreateCollection(title string, parentID int, isPublic bool) (*t
 	if parentID > 0 {
 		reqBody.Parent = &types.CollectionRef{ID: parentID}
 	}
+	return c.CreateCollectionFrom(ctx, reqBody)
+}
 
-	respBody, err := c.makeRequest("POST", "/collection", reqBody)
+// CreateCollectionFrom creates a collection with every field of reqBody,
+// including its appearance
+func (c *Client) CreateCollectionFrom(ctx context.Context, reqBody types.CreateCollectionRequest) (*types.Collection, error) {
+	respBody, err := c.makeRequest(ctx, "POST", "/collection", reqBody)
 	if err != nil {
 		return nil, err
 	}
@@ -31,8 +38,8 @@ func (c *Client) CreateCollection(title string, parentID int, isPublic bool) (*t
 }
 
 // GetCollection retrieves a single collection by ID
-func (c *Client) GetCollection(id int) (*types.Collection, error) {
-	respBody, err := c.makeRequest("GET", fmt.Sprintf("/collection/%d", id), nil)
+func (c *Client) GetCollection(ctx context.Context, id int) (*types.Collection, error) {
+	respBody, err := c.makeRequest(ctx, "GET", fmt.Sprintf("/collection/%d", id), nil)
 	if err != nil {
 		return nil, err
 	}
@@ -46,7 +53,7 @@ func (c *Client) GetCollection(id int) (*types.Collection, error) {
 }
 
 // UpdateCollection updates an existing collection
-func (c *Client) UpdateCollection(id int, title string, isPublic *bool, parentID *int) (*types.Collection, error) {
+func (c *Client) UpdateCollection(ctx context.Context, id int, title string, isPublic *bool, parentID *int) (*types.Collection, error) {
 	reqBody := types.UpdateCollectionRequest{}
 
 	if title != "" {
@@ -58,8 +65,34 @@ func (c *Client) UpdateCollection(id int, title string, isPublic *bool, parentID
 	if parentID != nil {
 		reqBody.Parent = &types.CollectionRef{ID: *parentID}
 	}
+	return c.PatchCollection(ctx, id, reqBody)
+}
+
+// PatchCollection applies patch to an existing collection
+func (c *Client) PatchCollection(ctx context.Context, id int, patch types.UpdateCollectionRequest) (*types.Collection, error) {
+	respBody, err := c.makeRequest(ctx, "PUT", fmt.Sprintf("/collection/%d", id), patch)
+	if err != nil {
+		return nil, err
+	}
+
+	var resp types.SingleCollectionResponse
+	if err := json.Unmarshal(respBody, &resp); err != nil {
+		return nil, fmt.Errorf("failed to parse response: %w", err)
+	}
+
+	return &resp.Item, nil
+}
+
+// MoveCollection moves a collection, with its subcollections, under another
+// collection, or to the top level when parentID is 0
+func (c *Client) MoveCollection(ctx context.Context, id, parentID int) (*types.Collection, error) {
+	// A null parent makes the collection a root collection
+	reqBody := map[string]any{"parent": nil}
+	if parentID != 0 {
+		reqBody["parent"] = types.CollectionRef{ID: parentID}
+	}
 
-	respBody, err := c.makeRequest("PUT", fmt.Sprintf("/collection/%d", id), reqBody)
+	respBody, err := c.makeRequest(ctx, "PUT", fmt.Sprintf("/collection/%d", id), reqBody)
 	if err != nil {
 		return nil, err
 	}
@@ -72,18 +105,33 @@ func (c *Client) UpdateCollection(id int, title string, isPublic *bool, parentID
 	return &resp.Item, nil
 }
 
+// SearchCovers searches Raindrop's icon library for collection covers
+func (c *Client) SearchCovers(ctx context.Context, text string) ([]types.CoverGroup, error) {
+	respBody, err := c.makeRequest(ctx, "GET", "/collections/covers/"+url.PathEscape(text), nil)
+	if err != nil {
+		return nil, err
+	}
+
+	var resp types.CoversResponse
+	if err := json.Unmarshal(respBody, &resp); err != nil {
+		return nil, fmt.Errorf("failed to parse response: %w", err)
+	}
+
+	return resp.Items, nil
+}
+
 // DeleteCollection removes a collection
-func (c *Client) DeleteCollection(id int) error {
-	_, err := c.makeRequest("DELETE", fmt.Sprintf("/collection/%d", id), nil)
+func (c *Client) DeleteCollection(ctx context.Context, id int) error {
+	_, err := c.makeRequest(ctx, "DELETE", fmt.Sprintf("/collection/%d", id), nil)
 	return err
 }
 
 // MergeCollections merges collections into target
-func (c *Client) MergeCollections(ids []int, targetID int) error {
+func (c *Client) MergeCollections(ctx context.Context, ids []int, targetID int) error {
 	reqBody := map[string]any{
 		"to":  targetID,
 		"ids": ids,
 	}
-	_, err := c.makeRequest("PUT", "/collections/merge", reqBody)
+	_, err := c.makeRequest(ctx, "PUT", "/collections/merge", reqBody)
 	return err
 }
@@ -1,6 +1,7 @@
 package api
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"net/url"
@@ -9,7 +10,7 @@ import (
 )
 
 // RenameTag renames a tag in a collection (0 for all)
-func (c *Client) RenameTag(collectionID int, oldName, newName string) error {
+func (c *Client) RenameTag(ctx context.Context, collectionID int, oldName, newName string) error {
 	reqBody := []types.RenameTagRequest{
 		{OldName: oldName, NewName: newName},
 	}
@@ -19,12 +20,12 @@ func (c *Client) RenameTag(collectionID int, oldName, newName string) error {
 		endpoint = fmt.Sprintf("/tags/%d", collectionID)
 	}
 
-	_, err := c.makeRequest("PUT", endpoint, reqBody)
+	_, err := c.makeRequest(ctx, "PUT", endpoint, reqBody)
 	return err
 }
 
 // DeleteTags deletes tags in a collection (0 for all)
-func (c *Client) DeleteTags(collectionID int, tags []string) error {
+func (c *Client) DeleteTags(ctx context.Context, collectionID int, tags []string) error {
 	reqBody := map[string][]string{
 		"tags": tags,
 	}
@@ -34,12 +35,12 @@ func (c *Client) DeleteTags(collectionID int, tags []string) error {
 		endpoint = fmt.Sprintf("/tags/%d", collectionID)
 	}
 
-	_, err := c.makeRequest("DELETE", endpoint, reqBody)
+	_, err := c.makeRequest(ctx, "DELETE", endpoint, reqBody)
 	return err
 }
 
 // MergeTags merges multiple tags into one
-func (c *Client) MergeTags(collectionID int, tags []string) error {
+func (c *Client) MergeTags(ctx context.Context, collectionID int, tags []string) error {
 	reqBody := types.MergeTagsRequest{
 		Tags: tags,
 	}
@@ -49,12 +50,12 @@ func (c *Client) MergeTags(collectionID int, tags []string) error {
 		endpoint = fmt.Sprintf("/tags/%d/merge", collectionID)
 	}
 
-	_, err := c.makeRequest("PUT", endpoint, reqBody)
+	_, err := c.makeRequest(ctx, "PUT", endpoint, reqBody)
 	return err
 }
 
 // GetHighlights gets all highlights for a raindrop
-func (c *Client) GetHighlights(raindropID int) (*types.HighlightsResponse, error) {
+func (c *Client) GetHighlights(ctx context.Context, raindropID int) (*types.HighlightsResponse, error) {
 	endpoint := fmt.Sprintf("/raindrop/%d/highlights", raindropID)
 
 	// If raindropID is 0, get all highlights
@@ -62,7 +63,7 @@ func (c *Client) GetHighlights(raindropID int) (*types.HighlightsResponse, error
 		endpoint = "/highlights"
 	}
 
-	respBody, err := c.makeRequest("GET", endpoint, nil)
+	respBody, err := c.makeRequest(ctx, "GET", endpoint, nil)
 	if err != nil {
 		return nil, err
 	}
@@ -76,7 +77,7 @@ func (c *Client) GetHighlights(raindropID int) (*types.HighlightsResponse, error
 }
 
 // CreateHighlight creates a new highlight
-func (c *Client) CreateHighlight(raindropID int, text, note, color string) (*types.Highlight, error) {
+func (c *Client) CreateHighlight(ctx context.Context, raindropID int, text, note, color string) (*types.Highlight, error) {
 	reqBody := types.CreateHighlightRequest{
 		RaindropID: raindropID,
 		Text:       text,
@@ -84,7 +85,7 @@ func (c *Client) CreateHighlight(raindropID int, text, note, color string) (*typ
 		Color:      color,
 	}
 
-	respBody, err := c.makeRequest("POST", "/highlight", reqBody)
+	respBody, err := c.makeRequest(ctx, "POST", "/highlight", reqBody)
 	if err != nil {
 		return nil, err
 	}
@@ -98,14 +99,14 @@ func (c *Client) CreateHighlight(raindropID int, text, note, color string) (*typ
 }
 
 // DeleteHighlight deletes a highlight
-func (c *Client) DeleteHighlight(raindropID int, highlightID string) error {
-	_, err := c.makeRequest("DELETE", fmt.Sprintf("/raindrop/%d/highlight/%s", raindropID, highlightID), nil)
+func (c *Client) DeleteHighlight(ctx context.Context, raindropID int, highlightID string) error {
+	_, err := c.makeRequest(ctx, "DELETE", fmt.Sprintf("/raindrop/%d/highlight/%s", raindropID, highlightID), nil)
 	return err
 }
 
 // GetFilters gets filters for a collection
-func (c *Client) GetFilters(collectionID int) (*types.FiltersResponse, error) {
-	respBody, err := c.makeRequest("GET", fmt.Sprintf("/filters/%d", collectionID), nil)
+func (c *Client) GetFilters(ctx context.Context, collectionID int) (*types.FiltersResponse, error) {
+	respBody, err := c.makeRequest(ctx, "GET", fmt.Sprintf("/filters/%d", collectionID), nil)
 	if err != nil {
 		return nil, err
 	}
@@ -119,8 +120,8 @@ func (c *Client) GetFilters(collectionID int) (*types.FiltersResponse, error) {
 }
 
 // GetUser gets current user info
-func (c *Client) GetUser() (*types.User, error) {
-	respBody, err := c.makeRequest("GET", "/user", nil)
+func (c *Client) GetUser(ctx context.Context) (*types.User, error) {
+	respBody, err := c.makeRequest(ctx, "GET", "/user", nil)
 	if err != nil {
 		return nil, err
 	}
@@ -134,8 +135,8 @@ func (c *Client) GetUser() (*types.User, error) {
 }
 
 // SuggestTags suggests tags for a URL
-func (c *Client) SuggestTags(inputURL string) ([]string, error) {
-	respBody, err := c.makeRequest("GET", fmt.Sprintf("/tags/suggest?url=%s", url.QueryEscape(inputURL)), nil)
+func (c *Client) SuggestTags(ctx context.Context, inputURL string) ([]string, error) {
+	respBody, err := c.makeRequest(ctx, "GET", fmt.Sprintf("/tags/suggest?url=%s", url.QueryEscape(inputURL)), nil)
 	if err != nil {
 		return nil, err
 	}
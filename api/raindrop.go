@@ -2,77 +2,212 @@ package api
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
+	"log/slog"
 	"net/http"
 	"net/url"
 	"strconv"
+	"strings"
 	"time"
 
 	"raindrop-mcp/types"
 )
 
-const baseURL = "https://api.raindrop.io/rest/v1"
+const (
+	defaultBaseURL = "https://api.raindrop.io/rest/v1"
+	defaultTimeout = 30 * time.Second
+)
 
 // Maximum response size (10MB)
 const maxResponseSize = 10 * 1024 * 1024
 
+// Rate limit handling: a 429 response is retried up to maxRateLimitRetries
+// times, waiting as long as the API asks but no longer than maxRateLimitWait
+const (
+	maxRateLimitRetries  = 3
+	defaultRateLimitWait = 5 * time.Second
+	maxRateLimitWait     = time.Minute
+)
+
 // Client is the Raindrop.io API client
 type Client struct {
-	token      string
-	httpClient *http.Client
+	token       string
+	tokenSource func() (string, error)
+	baseURL     string
+	httpClient  *http.Client
+	cache       *responseCache
+}
+
+// Options configures a Client
+type Options struct {
+	// BaseURL overrides the Raindrop REST API root
+	BaseURL string
+	// Timeout bounds each request (default 30s)
+	Timeout time.Duration
+	// CacheTTL enables caching of GET responses for this long (0 disables)
+	CacheTTL time.Duration
+	// TokenSource, if set, supplies the token for every request instead of
+	// the fixed token, e.g. to refresh it when it expires
+	TokenSource func() (string, error)
 }
 
 // NewClient creates a new Raindrop API client
 func NewClient(token string) *Client {
-	return &Client{
-		token: token,
+	return NewClientWithOptions(token, Options{})
+}
+
+// NewClientWithOptions creates a Raindrop API client with custom settings
+func NewClientWithOptions(token string, opts Options) *Client {
+	if opts.BaseURL == "" {
+		opts.BaseURL = defaultBaseURL
+	}
+	if opts.Timeout <= 0 {
+		opts.Timeout = defaultTimeout
+	}
+
+	c := &Client{
+		token:       token,
+		tokenSource: opts.TokenSource,
+		baseURL:     strings.TrimRight(opts.BaseURL, "/"),
 		httpClient: &http.Client{
-			Timeout: 30 * time.Second,
+			Timeout: opts.Timeout,
 		},
 	}
+	if opts.CacheTTL > 0 {
+		c.cache = newResponseCache(opts.CacheTTL)
+	}
+	return c
 }
 
-// makeRequest performs an HTTP request to the Raindrop API
-func (c *Client) makeRequest(method, endpoint string, body any) ([]byte, error) {
-	var reqBody io.Reader
+// Uncached returns a client sharing c's settings that always reads from the
+// API, for callers that poll for changes. It must only be used for reads:
+// its writes do not invalidate c's cache.
+func (c *Client) Uncached() *Client {
+	uncached := *c
+	uncached.cache = nil
+	return &uncached
+}
+
+// StatusError is returned when the API answers with an error status
+type StatusError struct {
+	StatusCode int
+	Body       string
+}
+
+func (e *StatusError) Error() string {
+	return fmt.Sprintf("API error (status %d): %s", e.StatusCode, e.Body)
+}
+
+// IsNotFound reports whether err is the API saying the item does not exist
+func IsNotFound(err error) bool {
+	var se *StatusError
+	return errors.As(err, &se) && se.StatusCode == http.StatusNotFound
+}
+
+// makeRequest performs an HTTP request to the Raindrop API. Requests that
+// hit the rate limit are retried after the time the API asks for, unless ctx
+// is cancelled while waiting.
+func (c *Client) makeRequest(ctx context.Context, method, endpoint string, body any) ([]byte, error) {
+	if c.cache != nil {
+		// Any write may change what reads return
+		if method != http.MethodGet {
+			c.cache.clear()
+		} else if cached, ok := c.cache.get(endpoint); ok {
+			slog.Debug("Raindrop API cache hit", "endpoint", endpoint)
+			return cached, nil
+		}
+	}
+
+	var jsonBody []byte
 	if body != nil {
-		jsonBody, err := json.Marshal(body)
+		data, err := json.Marshal(body)
 		if err != nil {
 			return nil, fmt.Errorf("failed to marshal request body: %w", err)
 		}
+		jsonBody = data
+	}
+
+	respBody, status, header, err := c.do(ctx, method, endpoint, jsonBody)
+	for attempt := 0; err == nil && status == http.StatusTooManyRequests && attempt < maxRateLimitRetries; attempt++ {
+		wait := retryAfter(header)
+		slog.Warn("Raindrop API rate limit reached, retrying", "method", method, "endpoint", endpoint, "wait", wait)
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-time.After(wait):
+		}
+		respBody, status, header, err = c.do(ctx, method, endpoint, jsonBody)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if status >= 400 {
+		return nil, &StatusError{StatusCode: status, Body: string(respBody)}
+	}
+
+	if c.cache != nil && method == http.MethodGet {
+		c.cache.put(endpoint, respBody)
+	}
+
+	return respBody, nil
+}
+
+// do sends one request and returns the response body and status
+func (c *Client) do(ctx context.Context, method, endpoint string, jsonBody []byte) ([]byte, int, http.Header, error) {
+	var reqBody io.Reader
+	if jsonBody != nil {
 		reqBody = bytes.NewReader(jsonBody)
 	}
 
-	req, err := http.NewRequest(method, baseURL+endpoint, reqBody)
+	req, err := http.NewRequestWithContext(ctx, method, c.baseURL+endpoint, reqBody)
 	if err != nil {
-		return nil, fmt.Errorf("failed to create request: %w", err)
+		return nil, 0, nil, fmt.Errorf("failed to create request: %w", err)
 	}
 
-	req.Header.Set("Authorization", "Bearer "+c.token)
+	token := c.token
+	if c.tokenSource != nil {
+		if token, err = c.tokenSource(); err != nil {
+			return nil, 0, nil, fmt.Errorf("failed to get access token: %w", err)
+		}
+	}
+
+	req.Header.Set("Authorization", "Bearer "+token)
 	req.Header.Set("Content-Type", "application/json")
 
+	start := time.Now()
 	resp, err := c.httpClient.Do(req)
 	if err != nil {
-		return nil, fmt.Errorf("request failed: %w", err)
+		slog.Warn("Raindrop API request failed", "method", method, "endpoint", endpoint, "error", err)
+		return nil, 0, nil, fmt.Errorf("request failed: %w", err)
 	}
 	defer resp.Body.Close()
+	slog.Debug("Raindrop API request", "method", method, "endpoint", endpoint, "status", resp.StatusCode, "duration", time.Since(start))
 
 	respBody, err := io.ReadAll(io.LimitReader(resp.Body, maxResponseSize))
 	if err != nil {
-		return nil, fmt.Errorf("failed to read response: %w", err)
+		return nil, 0, nil, fmt.Errorf("failed to read response: %w", err)
 	}
+	return respBody, resp.StatusCode, resp.Header, nil
+}
 
-	if resp.StatusCode >= 400 {
-		return nil, fmt.Errorf("API error (status %d): %s", resp.StatusCode, string(respBody))
+// retryAfter returns how long to wait after a 429 response, from the
+// Retry-After header or Raindrop's X-RateLimit-Reset epoch time
+func retryAfter(header http.Header) time.Duration {
+	wait := defaultRateLimitWait
+	if secs, err := strconv.Atoi(header.Get("Retry-After")); err == nil {
+		wait = time.Duration(secs) * time.Second
+	} else if reset, err := strconv.ParseInt(header.Get("X-RateLimit-Reset"), 10, 64); err == nil {
+		wait = time.Until(time.Unix(reset, 0))
 	}
-
-	return respBody, nil
+	return min(max(wait, time.Second), maxRateLimitWait)
 }
 
 // CreateRaindrop creates a new bookmark
-func (c *Client) CreateRaindrop(link, title string, tags []string, collectionID int) (*types.Raindrop, error) {
+func (c *Client) CreateRaindrop(ctx context.Context, link, title string, tags []string, collectionID int) (*types.Raindrop, error) {
 	reqBody := types.CreateRaindropRequest{
 		Link:        link,
 		Title:       title,
@@ -82,8 +217,13 @@ func (c *Client) CreateRaindrop(link, title string, tags []string, collectionID
 	if collectionID != 0 {
 		reqBody.Collection = &types.CollectionRef{ID: collectionID}
 	}
+	return c.CreateRaindropFrom(ctx, reqBody)
+}
 
-	respBody, err := c.makeRequest("POST", "/raindrop", reqBody)
+// CreateRaindropFrom creates a bookmark with every field of reqBody, such
+// as a copy of another bookmark
+func (c *Client) CreateRaindropFrom(ctx context.Context, reqBody types.CreateRaindropRequest) (*types.Raindrop, error) {
+	respBody, err := c.makeRequest(ctx, "POST", "/raindrop", reqBody)
 	if err != nil {
 		return nil, err
 	}
@@ -97,8 +237,8 @@ func (c *Client) CreateRaindrop(link, title string, tags []string, collectionID
 }
 
 // GetRaindrop retrieves a single bookmark by ID
-func (c *Client) GetRaindrop(id int) (*types.Raindrop, error) {
-	respBody, err := c.makeRequest("GET", fmt.Sprintf("/raindrop/%d", id), nil)
+func (c *Client) GetRaindrop(ctx context.Context, id int) (*types.Raindrop, error) {
+	respBody, err := c.makeRequest(ctx, "GET", fmt.Sprintf("/raindrop/%d", id), nil)
 	if err != nil {
 		return nil, err
 	}
@@ -111,24 +251,18 @@ func (c *Client) GetRaindrop(id int) (*types.Raindrop, error) {
 	return &resp.Item, nil
 }
 
-// UpdateRaindrop updates an existing bookmark
-func (c *Client) UpdateRaindrop(id int, title, note string, tags []string, collectionID *int) (*types.Raindrop, error) {
-	reqBody := types.UpdateRaindropRequest{}
-
-	if title != "" {
-		reqBody.Title = title
-	}
-	if note != "" {
-		reqBody.Note = note
-	}
-	if tags != nil {
-		reqBody.Tags = tags
-	}
-	if collectionID != nil {
-		reqBody.Collection = &types.CollectionRef{ID: *collectionID}
+// GetRaindropFresh retrieves a bookmark from the API even if a cached copy
+// exists, for updates that read the current value before writing
+func (c *Client) GetRaindropFresh(ctx context.Context, id int) (*types.Raindrop, error) {
+	if c.cache != nil {
+		c.cache.remove(fmt.Sprintf("/raindrop/%d", id))
 	}
+	return c.GetRaindrop(ctx, id)
+}
 
-	respBody, err := c.makeRequest("PUT", fmt.Sprintf("/raindrop/%d", id), reqBody)
+// UpdateRaindrop applies patch to an existing bookmark
+func (c *Client) UpdateRaindrop(ctx context.Context, id int, patch types.UpdateRaindropRequest) (*types.Raindrop, error) {
+	respBody, err := c.makeRequest(ctx, "PUT", fmt.Sprintf("/raindrop/%d", id), patch)
 	if err != nil {
 		return nil, err
 	}
@@ -142,13 +276,13 @@ func (c *Client) UpdateRaindrop(id int, title, note string, tags []string, colle
 }
 
 // DeleteRaindrop deletes a bookmark (moves to Trash)
-func (c *Client) DeleteRaindrop(id int) error {
-	_, err := c.makeRequest("DELETE", fmt.Sprintf("/raindrop/%d", id), nil)
+func (c *Client) DeleteRaindrop(ctx context.Context, id int) error {
+	_, err := c.makeRequest(ctx, "DELETE", fmt.Sprintf("/raindrop/%d", id), nil)
 	return err
 }
 
 // SearchRaindrops searches for bookmarks
-func (c *Client) SearchRaindrops(query string, collectionID, page, perPage int, tags []string) (*types.RaindropsResponse, error) {
+func (c *Client) SearchRaindrops(ctx context.Context, query string, collectionID, page, perPage int, tags []string) (*types.RaindropsResponse, error) {
 	params := url.Values{}
 	if query != "" {
 		params.Set("search", query)
@@ -165,7 +299,7 @@ func (c *Client) SearchRaindrops(query string, collectionID, page, perPage int,
 		endpoint += "?" + params.Encode()
 	}
 
-	respBody, err := c.makeRequest("GET", endpoint, nil)
+	respBody, err := c.makeRequest(ctx, "GET", endpoint, nil)
 	if err != nil {
 		return nil, err
 	}
@@ -179,8 +313,8 @@ func (c *Client) SearchRaindrops(query string, collectionID, page, perPage int,
 }
 
 // ListCollections returns all root collections
-func (c *Client) ListCollections() (*types.CollectionsResponse, error) {
-	respBody, err := c.makeRequest("GET", "/collections", nil)
+func (c *Client) ListCollections(ctx context.Context) (*types.CollectionsResponse, error) {
+	respBody, err := c.makeRequest(ctx, "GET", "/collections", nil)
 	if err != nil {
 		return nil, err
 	}
@@ -194,8 +328,8 @@ func (c *Client) ListCollections() (*types.CollectionsResponse, error) {
 }
 
 // ListChildCollections returns all nested collections
-func (c *Client) ListChildCollections() (*types.CollectionsResponse, error) {
-	respBody, err := c.makeRequest("GET", "/collections/childrens", nil)
+func (c *Client) ListChildCollections(ctx context.Context) (*types.CollectionsResponse, error) {
+	respBody, err := c.makeRequest(ctx, "GET", "/collections/childrens", nil)
 	if err != nil {
 		return nil, err
 	}
@@ -209,13 +343,13 @@ func (c *Client) ListChildCollections() (*types.CollectionsResponse, error) {
 }
 
 // GetTags returns all tags, optionally filtered by collection
-func (c *Client) GetTags(collectionID int) (*types.TagsResponse, error) {
+func (c *Client) GetTags(ctx context.Context, collectionID int) (*types.TagsResponse, error) {
 	endpoint := "/tags"
 	if collectionID != 0 {
 		endpoint = fmt.Sprintf("/tags/%d", collectionID)
 	}
 
-	respBody, err := c.makeRequest("GET", endpoint, nil)
+	respBody, err := c.makeRequest(ctx, "GET", endpoint, nil)
 	if err != nil {
 		return nil, err
 	}
@@ -0,0 +1,167 @@
+package auth
+
+import (
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/pbkdf2"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+)
+
+const (
+	encryptedVersion = 1
+	// pbkdf2Iterations follows the OWASP recommendation for PBKDF2-HMAC-SHA256
+	pbkdf2Iterations = 600000
+	saltSize         = 16
+	keySize          = 32
+	// nonceSize is the standard AES-GCM nonce size
+	nonceSize = 12
+)
+
+// encryptedFile is the on-disk format of an EncryptedFileStore
+type encryptedFile struct {
+	Version    int    `json:"version"`
+	KDF        string `json:"kdf"`
+	Iterations int    `json:"iterations"`
+	Salt       []byte `json:"salt"`
+	Nonce      []byte `json:"nonce"`
+	Ciphertext []byte `json:"ciphertext"`
+}
+
+// EncryptedFileStore keeps the token encrypted with a passphrase
+// (PBKDF2-SHA256 key derivation, AES-256-GCM)
+type EncryptedFileStore struct {
+	path       string
+	passphrase string
+}
+
+// NewEncryptedFileStore creates an encrypted store at path
+func NewEncryptedFileStore(path, passphrase string) (*EncryptedFileStore, error) {
+	if passphrase == "" {
+		return nil, errors.New("encrypted token store requires a passphrase")
+	}
+	return &EncryptedFileStore{path: path, passphrase: passphrase}, nil
+}
+
+// Load decrypts the token file
+func (s *EncryptedFileStore) Load() (*TokenData, error) {
+	data, err := os.ReadFile(s.path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to read token file: %w", err)
+	}
+
+	var file encryptedFile
+	if err := json.Unmarshal(data, &file); err != nil {
+		return nil, fmt.Errorf("failed to parse token file: %w", err)
+	}
+	if file.Version != encryptedVersion {
+		return nil, fmt.Errorf("unsupported token file version %d", file.Version)
+	}
+	// Check the parameters before deriving the key: a tampered iteration
+	// count could stall derivation and a bad nonce would make GCM panic
+	if file.Iterations != pbkdf2Iterations {
+		return nil, fmt.Errorf("unsupported key derivation iterations %d in token file", file.Iterations)
+	}
+	if len(file.Salt) != saltSize || len(file.Nonce) != nonceSize {
+		return nil, errors.New("token file is corrupt: bad salt or nonce length")
+	}
+
+	gcm, err := s.cipher(file.Salt, file.Iterations)
+	if err != nil {
+		return nil, err
+	}
+
+	plaintext, err := gcm.Open(nil, file.Nonce, file.Ciphertext, nil)
+	if err != nil {
+		return nil, errors.New("failed to decrypt token file (wrong passphrase?)")
+	}
+
+	var token TokenData
+	if err := json.Unmarshal(plaintext, &token); err != nil {
+		return nil, fmt.Errorf("failed to parse token: %w", err)
+	}
+
+	return &token, nil
+}
+
+// Save encrypts and atomically replaces the token file
+func (s *EncryptedFileStore) Save(token *TokenData) error {
+	plaintext, err := json.Marshal(token)
+	if err != nil {
+		return fmt.Errorf("failed to marshal token: %w", err)
+	}
+
+	salt := make([]byte, saltSize)
+	if _, err := rand.Read(salt); err != nil {
+		return fmt.Errorf("failed to generate salt: %w", err)
+	}
+
+	gcm, err := s.cipher(salt, pbkdf2Iterations)
+	if err != nil {
+		return err
+	}
+
+	nonce := make([]byte, gcm.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return fmt.Errorf("failed to generate nonce: %w", err)
+	}
+
+	data, err := json.MarshalIndent(encryptedFile{
+		Version:    encryptedVersion,
+		KDF:        "pbkdf2-sha256",
+		Iterations: pbkdf2Iterations,
+		Salt:       salt,
+		Nonce:      nonce,
+		Ciphertext: gcm.Seal(nil, nonce, plaintext, nil),
+	}, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal token file: %w", err)
+	}
+
+	if err := writeFileAtomic(s.path, data, 0600); err != nil {
+		return fmt.Errorf("failed to write token file: %w", err)
+	}
+
+	return nil
+}
+
+// Delete removes the token file
+func (s *EncryptedFileStore) Delete() error {
+	if err := os.Remove(s.path); err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("failed to delete token file: %w", err)
+	}
+	return nil
+}
+
+// Location returns the token file path
+func (s *EncryptedFileStore) Location() string {
+	return s.path + " (encrypted)"
+}
+
+// Lock takes the file lock guarding the token file
+func (s *EncryptedFileStore) Lock(ctx context.Context) (func(), error) {
+	return lockFile(ctx, s.path+".lock")
+}
+
+// cipher derives the AES-GCM cipher for the given salt
+func (s *EncryptedFileStore) cipher(salt []byte, iterations int) (cipher.AEAD, error) {
+	key, err := pbkdf2.Key(sha256.New, s.passphrase, salt, iterations, keySize)
+	if err != nil {
+		return nil, fmt.Errorf("failed to derive key: %w", err)
+	}
+
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create cipher: %w", err)
+	}
+
+	return cipher.NewGCM(block)
+}
@@ -0,0 +1,145 @@
+package auth
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestEncryptedFileStoreRoundTrip(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "token.enc")
+	store, err := NewEncryptedFileStore(path, "correct horse")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := &TokenData{AccessToken: "access", RefreshToken: "refresh", ExpiresAt: 1700000000, TokenType: "Bearer"}
+	if err := store.Save(want); err != nil {
+		t.Fatalf("Save: %v", err)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(string(data), "access") || strings.Contains(string(data), "refresh") {
+		t.Errorf("token file contains the token in plain text:\n%s", data)
+	}
+
+	got, err := store.Load()
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	if *got != *want {
+		t.Errorf("Load = %+v, want %+v", got, want)
+	}
+}
+
+func TestEncryptedFileStoreMissingFile(t *testing.T) {
+	store, err := NewEncryptedFileStore(filepath.Join(t.TempDir(), "token.enc"), "secret")
+	if err != nil {
+		t.Fatal(err)
+	}
+	token, err := store.Load()
+	if token != nil || err != nil {
+		t.Errorf("Load = %v, %v; want nil, nil", token, err)
+	}
+}
+
+func TestEncryptedFileStoreRejects(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "token.enc")
+	store, err := NewEncryptedFileStore(path, "secret")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := store.Save(&TokenData{AccessToken: "access"}); err != nil {
+		t.Fatal(err)
+	}
+	saved, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name       string
+		passphrase string
+		corrupt    func(*encryptedFile)
+		wantErr    string
+	}{
+		{
+			name:       "wrong passphrase",
+			passphrase: "not the secret",
+			wantErr:    "wrong passphrase",
+		},
+		{
+			name:    "corrupted nonce",
+			corrupt: func(f *encryptedFile) { f.Nonce[0] ^= 0xff },
+			wantErr: "wrong passphrase",
+		},
+		{
+			name:    "short nonce",
+			corrupt: func(f *encryptedFile) { f.Nonce = f.Nonce[:4] },
+			wantErr: "bad salt or nonce length",
+		},
+		{
+			name:    "short salt",
+			corrupt: func(f *encryptedFile) { f.Salt = f.Salt[:8] },
+			wantErr: "bad salt or nonce length",
+		},
+		{
+			name:    "corrupted ciphertext",
+			corrupt: func(f *encryptedFile) { f.Ciphertext[0] ^= 0xff },
+			wantErr: "wrong passphrase",
+		},
+		{
+			name:    "tampered iterations",
+			corrupt: func(f *encryptedFile) { f.Iterations = 1 << 30 },
+			wantErr: "unsupported key derivation iterations",
+		},
+		{
+			name:    "unknown version",
+			corrupt: func(f *encryptedFile) { f.Version = 2 },
+			wantErr: "unsupported token file version",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var file encryptedFile
+			if err := json.Unmarshal(saved, &file); err != nil {
+				t.Fatal(err)
+			}
+			if tt.corrupt != nil {
+				tt.corrupt(&file)
+			}
+			data, err := json.Marshal(file)
+			if err != nil {
+				t.Fatal(err)
+			}
+			casePath := filepath.Join(dir, strings.ReplaceAll(tt.name, " ", "-")+".enc")
+			if err := os.WriteFile(casePath, data, 0600); err != nil {
+				t.Fatal(err)
+			}
+
+			passphrase := tt.passphrase
+			if passphrase == "" {
+				passphrase = "secret"
+			}
+			store, err := NewEncryptedFileStore(casePath, passphrase)
+			if err != nil {
+				t.Fatal(err)
+			}
+			token, err := store.Load()
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Fatalf("Load = %v, %v; want error containing %q", token, err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestNewEncryptedFileStoreRequiresPassphrase(t *testing.T) {
+	if _, err := NewEncryptedFileStore(filepath.Join(t.TempDir(), "token.enc"), ""); err == nil {
+		t.Error("NewEncryptedFileStore accepted an empty passphrase")
+	}
+}
@@ -0,0 +1,105 @@
+package auth
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"time"
+)
+
+const (
+	// lockRetryInterval is how often a held lock is polled
+	lockRetryInterval = 100 * time.Millisecond
+	// lockTimeout bounds how long Lock waits for another process
+	lockTimeout = 30 * time.Second
+	// lockStaleAfter is the age after which a lock left by a crashed process is broken
+	lockStaleAfter = 2 * time.Minute
+)
+
+// lockFile acquires an exclusive lock by creating path with O_EXCL.
+// This works the same on every platform and needs no syscalls. The file
+// holds a random owner token, so a process only ever removes its own lock.
+func lockFile(ctx context.Context, path string) (func(), error) {
+	token, err := lockToken()
+	if err != nil {
+		return nil, err
+	}
+	deadline := time.Now().Add(lockTimeout)
+
+	for {
+		f, err := os.OpenFile(path, os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0600)
+		if err == nil {
+			_, writeErr := f.WriteString(token)
+			closeErr := f.Close()
+			if err := errors.Join(writeErr, closeErr); err != nil {
+				os.Remove(path)
+				return nil, fmt.Errorf("failed to write lock file: %w", err)
+			}
+			return func() { unlockFile(path, token) }, nil
+		}
+		if !errors.Is(err, os.ErrExist) {
+			return nil, fmt.Errorf("failed to create lock file: %w", err)
+		}
+
+		// Break locks abandoned by a crashed process
+		if info, statErr := os.Stat(path); statErr == nil && time.Since(info.ModTime()) > lockStaleAfter {
+			slog.Warn("Breaking stale token lock", "path", path, "age", time.Since(info.ModTime()).Round(time.Second))
+			breakStaleLock(path, token)
+			continue
+		}
+
+		if time.Now().After(deadline) {
+			return nil, fmt.Errorf("timed out waiting for lock %s", path)
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-time.After(lockRetryInterval):
+		}
+	}
+}
+
+// lockToken returns a random token identifying a lock owner
+func lockToken() (string, error) {
+	buf := make([]byte, 16)
+	if _, err := rand.Read(buf); err != nil {
+		return "", fmt.Errorf("failed to generate lock token: %w", err)
+	}
+	return fmt.Sprintf("%d-%s", os.Getpid(), hex.EncodeToString(buf)), nil
+}
+
+// unlockFile removes the lock at path if it still holds token
+func unlockFile(path, token string) {
+	owner, err := os.ReadFile(path)
+	if err != nil || string(owner) != token {
+		slog.Warn("Token lock was taken over, leaving it in place", "path", path)
+		return
+	}
+	os.Remove(path)
+}
+
+// breakStaleLock removes a stale lock. The lock is first renamed aside, which
+// only one process can do, and checked to be the stale one: if another
+// process replaced it in the meantime, its fresh lock is put back.
+func breakStaleLock(path, token string) {
+	stale, err := os.ReadFile(path)
+	if err != nil {
+		return
+	}
+	aside := path + "." + token + ".stale"
+	if err := os.Rename(path, aside); err != nil {
+		// Another process broke or released it first
+		return
+	}
+	if moved, err := os.ReadFile(aside); err == nil && !bytes.Equal(moved, stale) {
+		os.Rename(aside, path)
+		return
+	}
+	os.Remove(aside)
+}
@@ -3,20 +3,30 @@ package auth
 import (
 	"bytes"
 	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
 	"encoding/json"
 	"fmt"
+	"html"
 	"io"
+	"log/slog"
 	"net"
 	"net/http"
 	"net/url"
 	"os/exec"
 	"runtime"
+	"strings"
+	"sync"
 	"time"
 )
 
 const (
 	authURL  = "https://raindrop.io/oauth/authorize"
 	tokenURL = "https://raindrop.io/oauth/access_token"
+
+	defaultCallbackPath = "/callback"
 )
 
 // OAuthConfig contains OAuth2 configuration
@@ -24,6 +34,16 @@ type OAuthConfig struct {
 	ClientID     string
 	ClientSecret string
 	RedirectURI  string
+
+	// RedirectPort fixes the local callback port (0 picks a free port).
+	// Use it when the app's redirect URI must be pre-registered.
+	RedirectPort int
+	// RedirectPath is the callback path (defaults to /callback)
+	RedirectPath string
+	// DisablePKCE skips the PKCE code challenge for providers that reject it
+	DisablePKCE bool
+
+	codeVerifier string
 }
 
 // tokenResponse represents the OAuth token response
@@ -35,99 +55,216 @@ type tokenResponse struct {
 	Error        string `json:"error,omitempty"`
 }
 
+// callbackResult carries the outcome of the single accepted callback
+type callbackResult struct {
+	code string
+	err  error
+}
+
 // StartOAuthFlow initiates the OAuth2 authorization flow
 // Opens browser for user authorization and waits for callback
 func StartOAuthFlow(ctx context.Context, config *OAuthConfig) (*TokenData, error) {
-	// Find available port
-	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	callbackPath, err := redirectPath(config.RedirectPath)
+	if err != nil {
+		return nil, err
+	}
+
+	// Listen on the fixed port if configured, otherwise any free port
+	listener, err := net.Listen("tcp", fmt.Sprintf("127.0.0.1:%d", config.RedirectPort))
 	if err != nil {
 		return nil, fmt.Errorf("failed to start callback server: %w", err)
 	}
 	port := listener.Addr().(*net.TCPAddr).Port
-	redirectURI := fmt.Sprintf("http://127.0.0.1:%d/callback", port)
+	redirectURI := fmt.Sprintf("http://127.0.0.1:%d%s", port, callbackPath)
 	config.RedirectURI = redirectURI
 
-	// Channel to receive authorization code
-	codeChan := make(chan string, 1)
-	errChan := make(chan error, 1)
+	state, err := randomString(32)
+	if err != nil {
+		listener.Close()
+		return nil, fmt.Errorf("failed to generate state: %w", err)
+	}
+
+	var codeChallenge string
+	config.codeVerifier = ""
+	if !config.DisablePKCE {
+		config.codeVerifier, codeChallenge, err = GeneratePKCE()
+		if err != nil {
+			listener.Close()
+			return nil, fmt.Errorf("failed to generate PKCE verifier: %w", err)
+		}
+	}
+
+	// Only the first callback carrying the expected state is accepted
+	resultChan := make(chan callbackResult, 1)
+	var once sync.Once
+	accept := func(res callbackResult) bool {
+		accepted := false
+		once.Do(func() {
+			accepted = true
+			resultChan <- res
+		})
+		return accepted
+	}
 
 	// Setup callback handler
 	mux := http.NewServeMux()
-	mux.HandleFunc("/callback", func(w http.ResponseWriter, r *http.Request) {
-		code := r.URL.Query().Get("code")
-		errParam := r.URL.Query().Get("error")
-
-		if errParam != "" {
-			w.Header().Set("Content-Type", "text/html")
-			fmt.Fprintf(w, "<html><body><h1>Authorization Failed</h1><p>Error: %s</p></body></html>", errParam)
-			errChan <- fmt.Errorf("authorization denied: %s", errParam)
+	mux.HandleFunc(callbackPath, func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
 			return
 		}
 
-		if code == "" {
-			w.Header().Set("Content-Type", "text/html")
-			fmt.Fprint(w, "<html><body><h1>Error</h1><p>No authorization code received</p></body></html>")
-			errChan <- fmt.Errorf("no authorization code received")
+		query := r.URL.Query()
+		if subtle.ConstantTimeCompare([]byte(query.Get("state")), []byte(state)) != 1 {
+			writeCallbackPage(w, http.StatusBadRequest, "Authorization Failed", "Invalid or missing state parameter.")
 			return
 		}
 
-		w.Header().Set("Content-Type", "text/html")
-		fmt.Fprint(w, `<html><body>
-			<h1>✅ Authorization Successful!</h1>
-			<p>You can close this window and return to your application.</p>
-			<script>setTimeout(function(){window.close();}, 2000);</script>
-		</body></html>`)
-		codeChan <- code
+		code := query.Get("code")
+		errParam := query.Get("error")
+
+		var res callbackResult
+		status, title, message := http.StatusOK, "✅ Authorization Successful!", "You can close this window and return to your application."
+		switch {
+		case errParam != "":
+			res.err = fmt.Errorf("authorization denied: %s", errParam)
+			status, title, message = http.StatusBadRequest, "Authorization Failed", "Error: "+errParam
+		case code == "":
+			res.err = fmt.Errorf("no authorization code received")
+			status, title, message = http.StatusBadRequest, "Error", "No authorization code received"
+		default:
+			res.code = code
+		}
+
+		if !accept(res) {
+			writeCallbackPage(w, http.StatusConflict, "Already Used", "This authorization callback has already been handled.")
+			return
+		}
+		writeCallbackPage(w, status, title, message)
 	})
 
-	server := &http.Server{Handler: mux}
+	server := &http.Server{Handler: mux, ReadHeaderTimeout: 10 * time.Second}
 
 	// Start server in background
 	go func() {
 		if err := server.Serve(listener); err != nil && err != http.ErrServerClosed {
-			errChan <- fmt.Errorf("callback server error: %w", err)
+			accept(callbackResult{err: fmt.Errorf("callback server error: %w", err)})
 		}
 	}()
 
 	// Build authorization URL
-	authParams := url.Values{}
-	authParams.Set("client_id", config.ClientID)
-	authParams.Set("redirect_uri", redirectURI)
-	authParams.Set("response_type", "code")
-	authURLFull := authURL + "?" + authParams.Encode()
+	authURLFull := AuthorizationURL(config, state, codeChallenge)
 
-	// Open browser
-	fmt.Printf("Opening browser for authorization...\n")
-	fmt.Printf("If browser doesn't open, visit: %s\n", authURLFull)
+	// Open browser (stdout belongs to the MCP transport)
+	slog.Info("Opening browser for authorization; if it doesn't open, visit the URL", "url", authURLFull)
 	if err := openBrowser(authURLFull); err != nil {
-		fmt.Printf("Failed to open browser: %v\n", err)
+		slog.Warn("Failed to open browser", "error", err)
 	}
 
-	// Wait for code or error
-	var code string
+	// Wait for the callback
+	var res callbackResult
 	select {
-	case code = <-codeChan:
-		// Success
-	case err := <-errChan:
-		server.Shutdown(ctx)
-		return nil, err
+	case res = <-resultChan:
 	case <-time.After(5 * time.Minute):
-		server.Shutdown(ctx)
-		return nil, fmt.Errorf("authorization timeout (5 minutes)")
+		res.err = fmt.Errorf("authorization timeout (5 minutes)")
 	case <-ctx.Done():
-		server.Shutdown(ctx)
-		return nil, ctx.Err()
+		res.err = ctx.Err()
 	}
 
 	// Shutdown callback server
-	server.Shutdown(ctx)
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	server.Shutdown(shutdownCtx)
+
+	if res.err != nil {
+		return nil, res.err
+	}
 
 	// Exchange code for token
-	return exchangeCodeForToken(config, code)
+	return exchangeCodeForToken(config, res.code)
+}
+
+// redirectPath checks the configured callback path, which is used both in the
+// redirect URI and as the callback server's route, so it must be a plain path
+func redirectPath(path string) (string, error) {
+	if path == "" {
+		return defaultCallbackPath, nil
+	}
+	if !strings.HasPrefix(path, "/") {
+		path = "/" + path
+	}
+	u, err := url.Parse(path)
+	if err != nil || u.Scheme != "" || u.Host != "" || u.RawQuery != "" || u.Fragment != "" ||
+		u.Path != path || strings.ContainsAny(path, " {}") {
+		return "", fmt.Errorf("invalid OAuth redirect path %q: it must be a plain absolute path such as %s", path, defaultCallbackPath)
+	}
+	return path, nil
+}
+
+// writeCallbackPage renders an HTML page for the browser, escaping all text
+func writeCallbackPage(w http.ResponseWriter, status int, title, message string) {
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.Header().Set("Content-Security-Policy", "default-src 'none'; script-src 'unsafe-inline'")
+	w.WriteHeader(status)
+	fmt.Fprintf(w, `<html><body>
+			<h1>%s</h1>
+			<p>%s</p>
+			<script>setTimeout(function(){window.close();}, 2000);</script>
+		</body></html>`, html.EscapeString(title), html.EscapeString(message))
+}
+
+// AuthorizationURL builds the Raindrop authorize URL for config.RedirectURI.
+// codeChallenge may be empty to skip PKCE.
+func AuthorizationURL(config *OAuthConfig, state, codeChallenge string) string {
+	authParams := url.Values{}
+	authParams.Set("client_id", config.ClientID)
+	authParams.Set("redirect_uri", config.RedirectURI)
+	authParams.Set("response_type", "code")
+	authParams.Set("state", state)
+	if codeChallenge != "" {
+		authParams.Set("code_challenge", codeChallenge)
+		authParams.Set("code_challenge_method", "S256")
+	}
+	return authURL + "?" + authParams.Encode()
+}
+
+// GenerateState returns a random OAuth state value
+func GenerateState() (string, error) {
+	return randomString(32)
+}
+
+// GeneratePKCE returns a PKCE code verifier and its S256 challenge
+func GeneratePKCE() (verifier, challenge string, err error) {
+	verifier, err = randomString(48)
+	if err != nil {
+		return "", "", err
+	}
+	return verifier, PKCEChallenge(verifier), nil
+}
+
+// PKCEChallenge returns the S256 code challenge for a verifier
+func PKCEChallenge(verifier string) string {
+	sum := sha256.Sum256([]byte(verifier))
+	return base64.RawURLEncoding.EncodeToString(sum[:])
+}
+
+// randomString returns n random bytes encoded as unpadded base64url
+func randomString(n int) (string, error) {
+	buf := make([]byte, n)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(buf), nil
 }
 
 // exchangeCodeForToken exchanges authorization code for access token
 func exchangeCodeForToken(config *OAuthConfig, code string) (*TokenData, error) {
+	return ExchangeCode(config, code, config.codeVerifier)
+}
+
+// ExchangeCode exchanges an authorization code issued for config.RedirectURI.
+// codeVerifier may be empty if no PKCE challenge was sent.
+func ExchangeCode(config *OAuthConfig, code, codeVerifier string) (*TokenData, error) {
 	reqBody := map[string]string{
 		"grant_type":    "authorization_code",
 		"code":          code,
@@ -135,6 +272,9 @@ func exchangeCodeForToken(config *OAuthConfig, code string) (*TokenData, error)
 		"client_secret": config.ClientSecret,
 		"redirect_uri":  config.RedirectURI,
 	}
+	if codeVerifier != "" {
+		reqBody["code_verifier"] = codeVerifier
+	}
 
 	return makeTokenRequest(reqBody)
 }
@@ -0,0 +1,161 @@
+package auth
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+)
+
+// DefaultProfile is the profile backed by the top-level ~/.raindrop-mcp files
+const DefaultProfile = "default"
+
+var profileNamePattern = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9_-]{0,63}$`)
+
+// Profile is a named Raindrop account with its own token file and settings
+type Profile struct {
+	Name     string
+	Dir      string
+	Settings ProfileSettings
+}
+
+// ProfileSettings are stored per profile in settings.json
+type ProfileSettings struct {
+	// Label is a human-friendly account description
+	Label string `json:"label,omitempty"`
+	// Token is a test token used instead of OAuth
+	Token string `json:"token,omitempty"`
+	// ClientID and ClientSecret select the OAuth app for this account
+	ClientID     string `json:"client_id,omitempty"`
+	ClientSecret string `json:"client_secret,omitempty"`
+}
+
+// ValidateProfileName checks that name is safe to use as a directory name
+func ValidateProfileName(name string) error {
+	if !profileNamePattern.MatchString(name) {
+		return fmt.Errorf("invalid profile name %q (use letters, digits, '-' and '_')", name)
+	}
+	return nil
+}
+
+// profilesDir returns ~/.raindrop-mcp/profiles
+func profilesDir() (string, error) {
+	configDir, err := ConfigDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(configDir, "profiles"), nil
+}
+
+// ListProfiles returns the names of all profiles, always including the default
+func ListProfiles() ([]string, error) {
+	names := []string{DefaultProfile}
+
+	dir, err := profilesDir()
+	if err != nil {
+		return nil, err
+	}
+
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return names, nil
+		}
+		return nil, fmt.Errorf("failed to read profiles directory: %w", err)
+	}
+
+	for _, e := range entries {
+		if e.IsDir() && e.Name() != DefaultProfile && ValidateProfileName(e.Name()) == nil {
+			names = append(names, e.Name())
+		}
+	}
+	sort.Strings(names[1:])
+
+	return names, nil
+}
+
+// LoadProfile loads a profile by name. The default profile lives directly in
+// ~/.raindrop-mcp; named profiles live in ~/.raindrop-mcp/profiles/<name>.
+func LoadProfile(name string) (*Profile, error) {
+	if name == "" {
+		name = DefaultProfile
+	}
+	if err := ValidateProfileName(name); err != nil {
+		return nil, err
+	}
+
+	var dir string
+	if name == DefaultProfile {
+		configDir, err := ConfigDir()
+		if err != nil {
+			return nil, err
+		}
+		dir = configDir
+	} else {
+		profiles, err := profilesDir()
+		if err != nil {
+			return nil, err
+		}
+		dir = filepath.Join(profiles, name)
+		if _, err := os.Stat(dir); err != nil {
+			if os.IsNotExist(err) {
+				return nil, fmt.Errorf("profile %q does not exist", name)
+			}
+			return nil, fmt.Errorf("failed to read profile %q: %w", name, err)
+		}
+	}
+
+	profile := &Profile{Name: name, Dir: dir}
+
+	data, err := os.ReadFile(filepath.Join(dir, "settings.json"))
+	if err != nil && !os.IsNotExist(err) {
+		return nil, fmt.Errorf("failed to read profile settings: %w", err)
+	}
+	if err == nil {
+		if err := json.Unmarshal(data, &profile.Settings); err != nil {
+			return nil, fmt.Errorf("failed to parse profile settings: %w", err)
+		}
+	}
+
+	return profile, nil
+}
+
+// CreateProfile creates the directory for a named profile if it does not exist
+func CreateProfile(name string) (*Profile, error) {
+	if err := ValidateProfileName(name); err != nil {
+		return nil, err
+	}
+	if name != DefaultProfile {
+		profiles, err := profilesDir()
+		if err != nil {
+			return nil, err
+		}
+		if err := os.MkdirAll(filepath.Join(profiles, name), 0700); err != nil {
+			return nil, fmt.Errorf("failed to create profile directory: %w", err)
+		}
+	}
+	return LoadProfile(name)
+}
+
+// SaveSettings writes the profile's settings.json
+func (p *Profile) SaveSettings() error {
+	data, err := json.MarshalIndent(p.Settings, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal profile settings: %w", err)
+	}
+	if err := writeFileAtomic(filepath.Join(p.Dir, "settings.json"), data, 0600); err != nil {
+		return fmt.Errorf("failed to write profile settings: %w", err)
+	}
+	return nil
+}
+
+// TokenStore returns the store for this profile's OAuth token, encrypted when
+// a passphrase is given
+func (p *Profile) TokenStore(passphrase string) (TokenStore, error) {
+	if passphrase != "" {
+		return NewEncryptedFileStore(filepath.Join(p.Dir, "token.enc"), passphrase)
+	}
+	return NewFileStore(filepath.Join(p.Dir, "token.json")), nil
+}
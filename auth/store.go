@@ -0,0 +1,229 @@
+package auth
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// DockerSecretPath is where Docker and Compose mount the raindrop_token secret
+const DockerSecretPath = "/run/secrets/raindrop_token"
+
+// ErrReadOnly is returned when writing to a read-only token store
+var ErrReadOnly = errors.New("token store is read-only")
+
+// TokenStore persists OAuth token data
+type TokenStore interface {
+	// Load returns the stored token, or nil if none is saved
+	Load() (*TokenData, error)
+	// Save replaces the stored token
+	Save(token *TokenData) error
+	// Delete removes the stored token; deleting a missing token is not an error
+	Delete() error
+	// Location describes where the token lives, for diagnostics
+	Location() string
+}
+
+// Locker is implemented by stores that can be locked across processes
+type Locker interface {
+	// Lock blocks until the store is locked and returns a function releasing it
+	Lock(ctx context.Context) (unlock func(), err error)
+}
+
+// FileStore keeps the token as plaintext JSON in a file
+type FileStore struct {
+	path string
+}
+
+// NewFileStore creates a file store at path
+func NewFileStore(path string) *FileStore {
+	return &FileStore{path: path}
+}
+
+// DefaultFileStore returns the store at ~/.raindrop-mcp/token.json
+func DefaultFileStore() (*FileStore, error) {
+	tokenPath, err := getTokenPath()
+	if err != nil {
+		return nil, err
+	}
+	return NewFileStore(tokenPath), nil
+}
+
+// Load reads the token file
+func (s *FileStore) Load() (*TokenData, error) {
+	data, err := os.ReadFile(s.path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil // No token saved
+		}
+		return nil, fmt.Errorf("failed to read token file: %w", err)
+	}
+
+	var token TokenData
+	if err := json.Unmarshal(data, &token); err != nil {
+		return nil, fmt.Errorf("failed to parse token file: %w", err)
+	}
+
+	return &token, nil
+}
+
+// Save atomically replaces the token file
+func (s *FileStore) Save(token *TokenData) error {
+	data, err := json.MarshalIndent(token, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal token: %w", err)
+	}
+
+	if err := writeFileAtomic(s.path, data, 0600); err != nil {
+		return fmt.Errorf("failed to write token file: %w", err)
+	}
+
+	return nil
+}
+
+// Delete removes the token file
+func (s *FileStore) Delete() error {
+	if err := os.Remove(s.path); err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("failed to delete token file: %w", err)
+	}
+	return nil
+}
+
+// Location returns the token file path
+func (s *FileStore) Location() string {
+	return s.path
+}
+
+// Lock takes the file lock guarding the token file
+func (s *FileStore) Lock(ctx context.Context) (func(), error) {
+	return lockFile(ctx, s.path+".lock")
+}
+
+// ReadOnlyStore reads a token from a file managed outside the server,
+// such as RAINDROP_TOKEN_FILE or a Docker secret.
+// The file holds either token JSON or a bare access token.
+type ReadOnlyStore struct {
+	path string
+}
+
+// NewReadOnlyStore creates a read-only store backed by path
+func NewReadOnlyStore(path string) *ReadOnlyStore {
+	return &ReadOnlyStore{path: path}
+}
+
+// Load reads the token file
+func (s *ReadOnlyStore) Load() (*TokenData, error) {
+	data, err := os.ReadFile(s.path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to read token file: %w", err)
+	}
+
+	content := strings.TrimSpace(string(data))
+	if content == "" {
+		return nil, nil
+	}
+
+	if strings.HasPrefix(content, "{") {
+		var token TokenData
+		if err := json.Unmarshal([]byte(content), &token); err != nil {
+			return nil, fmt.Errorf("failed to parse token file: %w", err)
+		}
+		return &token, nil
+	}
+
+	return &TokenData{AccessToken: content}, nil
+}
+
+// Save always fails with ErrReadOnly
+func (s *ReadOnlyStore) Save(*TokenData) error {
+	return ErrReadOnly
+}
+
+// Delete always fails with ErrReadOnly
+func (s *ReadOnlyStore) Delete() error {
+	return ErrReadOnly
+}
+
+// Location returns the token file path
+func (s *ReadOnlyStore) Location() string {
+	return s.path + " (read-only)"
+}
+
+// RefreshIfExpired loads the token from store and refreshes it when expired.
+// Stores implementing Locker stay locked while refreshing, so concurrent server
+// instances sharing a token file refresh it only once.
+// Returns nil if the store holds no token.
+func RefreshIfExpired(ctx context.Context, store TokenStore, config *OAuthConfig) (*TokenData, error) {
+	if locker, ok := store.(Locker); ok {
+		unlock, err := locker.Lock(ctx)
+		if err != nil {
+			return nil, err
+		}
+		defer unlock()
+	}
+
+	token, err := store.Load()
+	if err != nil || token == nil {
+		return nil, err
+	}
+
+	// Tokens without a refresh token (e.g. test tokens) are used as-is
+	if token.RefreshToken == "" || !token.IsExpired() {
+		return token, nil
+	}
+
+	if config == nil {
+		return nil, fmt.Errorf("token expired and no OAuth client configured to refresh it")
+	}
+
+	slog.DebugContext(ctx, "Refreshing expired token", "location", store.Location())
+	newToken, err := RefreshToken(config, token.RefreshToken)
+	if err != nil {
+		return nil, fmt.Errorf("failed to refresh token: %w", err)
+	}
+
+	if err := store.Save(newToken); err != nil && !errors.Is(err, ErrReadOnly) {
+		return nil, fmt.Errorf("failed to save refreshed token: %w", err)
+	}
+	slog.InfoContext(ctx, "Refreshed token", "location", store.Location(), "expires", time.Unix(newToken.ExpiresAt, 0))
+
+	return newToken, nil
+}
+
+// writeFileAtomic writes data to a temp file in the same directory and renames it over path
+func writeFileAtomic(path string, data []byte, perm os.FileMode) error {
+	dir := filepath.Dir(path)
+	tmp, err := os.CreateTemp(dir, "."+filepath.Base(path)+".*.tmp")
+	if err != nil {
+		return err
+	}
+	tmpName := tmp.Name()
+	defer os.Remove(tmpName) // no-op after a successful rename
+
+	if err := tmp.Chmod(perm); err != nil {
+		tmp.Close()
+		return err
+	}
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+
+	return os.Rename(tmpName, path)
+}
@@ -1,7 +1,6 @@
 package auth
 
 import (
-	"encoding/json"
 	"fmt"
 	"os"
 	"path/filepath"
@@ -27,8 +26,8 @@ func (t *TokenData) IsValid() bool {
 	return t.AccessToken != "" && t.RefreshToken != ""
 }
 
-// getTokenPath returns the path to token storage file
-func getTokenPath() (string, error) {
+// ConfigDir returns ~/.raindrop-mcp, creating it if needed
+func ConfigDir() (string, error) {
 	homeDir, err := os.UserHomeDir()
 	if err != nil {
 		return "", fmt.Errorf("failed to get home directory: %w", err)
@@ -39,61 +38,42 @@ func getTokenPath() (string, error) {
 		return "", fmt.Errorf("failed to create config directory: %w", err)
 	}
 
-	return filepath.Join(configDir, "token.json"), nil
+	return configDir, nil
 }
 
-// SaveToken saves token data to file
-func SaveToken(token *TokenData) error {
-	tokenPath, err := getTokenPath()
+// getTokenPath returns the path to token storage file
+func getTokenPath() (string, error) {
+	configDir, err := ConfigDir()
 	if err != nil {
-		return err
+		return "", err
 	}
 
-	data, err := json.MarshalIndent(token, "", "  ")
-	if err != nil {
-		return fmt.Errorf("failed to marshal token: %w", err)
-	}
+	return filepath.Join(configDir, "token.json"), nil
+}
 
-	if err := os.WriteFile(tokenPath, data, 0600); err != nil {
-		return fmt.Errorf("failed to write token file: %w", err)
+// SaveToken saves token data to the default token file
+func SaveToken(token *TokenData) error {
+	store, err := DefaultFileStore()
+	if err != nil {
+		return err
 	}
-
-	return nil
+	return store.Save(token)
 }
 
-// LoadToken loads token data from file
+// LoadToken loads token data from the default token file
 func LoadToken() (*TokenData, error) {
-	tokenPath, err := getTokenPath()
+	store, err := DefaultFileStore()
 	if err != nil {
 		return nil, err
 	}
-
-	data, err := os.ReadFile(tokenPath)
-	if err != nil {
-		if os.IsNotExist(err) {
-			return nil, nil // No token saved
-		}
-		return nil, fmt.Errorf("failed to read token file: %w", err)
-	}
-
-	var token TokenData
-	if err := json.Unmarshal(data, &token); err != nil {
-		return nil, fmt.Errorf("failed to parse token file: %w", err)
-	}
-
-	return &token, nil
+	return store.Load()
 }
 
 // DeleteToken removes the saved token
 func DeleteToken() error {
-	tokenPath, err := getTokenPath()
+	store, err := DefaultFileStore()
 	if err != nil {
 		return err
 	}
-
-	if err := os.Remove(tokenPath); err != nil && !os.IsNotExist(err) {
-		return fmt.Errorf("failed to delete token file: %w", err)
-	}
-
-	return nil
+	return store.Delete()
 }
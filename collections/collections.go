@@ -0,0 +1,279 @@
+package collections
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+
+	"raindrop-mcp/api"
+	"raindrop-mcp/types"
+
+	"github.com/google/jsonschema-go/jsonschema"
+)
+
+// System collection IDs
+const (
+	All      = 0
+	Unsorted = -1
+	Trash    = -99
+)
+
+// special maps the names of system collections to their IDs
+var special = map[string]int{
+	"all":      All,
+	"unsorted": Unsorted,
+	"trash":    Trash,
+}
+
+// maxSuggestions limits the candidates listed in resolver errors
+const maxSuggestions = 5
+
+// Ref refers to a collection by ID, title, slash-separated path such as
+// "Work/Go", or one of the names all, unsorted and trash. It decodes from
+// a JSON number or string; the empty Ref means no collection was given.
+type Ref string
+
+// UnmarshalJSON accepts a number or a string
+func (r *Ref) UnmarshalJSON(data []byte) error {
+	var s string
+	if err := json.Unmarshal(data, &s); err == nil {
+		*r = Ref(s)
+		return nil
+	}
+	var id int
+	if err := json.Unmarshal(data, &id); err != nil {
+		return fmt.Errorf("collection must be an ID, title or path, got %s", data)
+	}
+	*r = Ref(strconv.Itoa(id))
+	return nil
+}
+
+// RefSchema is the JSON schema of Ref in tool inputs
+func RefSchema() *jsonschema.Schema {
+	return &jsonschema.Schema{Types: []string{"integer", "string"}}
+}
+
+// Index is a user's collections, with the path of each
+type Index struct {
+	Collections []types.Collection
+	byID        map[int]int // ID -> position in Collections
+}
+
+// Load lists the root and child collections of the client's account
+func Load(ctx context.Context, client *api.Client) (*Index, error) {
+	rootCollections, err := client.ListCollections(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list collections: %w", err)
+	}
+	childCollections, err := client.ListChildCollections(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list child collections: %w", err)
+	}
+	return NewIndex(append(rootCollections.Items, childCollections.Items...)), nil
+}
+
+// NewIndex indexes a list of collections, putting them in tree order: each
+// collection is followed by its subcollections
+func NewIndex(collections []types.Collection) *Index {
+	x := newIndex(collections)
+	ordered := make([]types.Collection, 0, len(collections))
+	Walk(x.Tree(), func(n *Node) {
+		ordered = append(ordered, n.Collection)
+	})
+	return newIndex(ordered)
+}
+
+func newIndex(collections []types.Collection) *Index {
+	x := &Index{Collections: collections, byID: make(map[int]int, len(collections))}
+	for i, c := range collections {
+		x.byID[c.FullID] = i
+	}
+	return x
+}
+
+// Get returns the collection with the given ID
+func (x *Index) Get(id int) (types.Collection, bool) {
+	i, ok := x.byID[id]
+	if !ok {
+		return types.Collection{}, false
+	}
+	return x.Collections[i], true
+}
+
+// ParentID returns the ID of a collection's parent, or 0 for root collections
+func ParentID(c types.Collection) int {
+	if c.Parent == nil {
+		return 0
+	}
+	return c.Parent.ID
+}
+
+// Titles returns the titles from the root down to the collection. A parent
+// that is not in the index ends the path.
+func (x *Index) Titles(id int) []string {
+	var titles []string
+	for seen := 0; seen <= len(x.Collections); seen++ {
+		c, ok := x.Get(id)
+		if !ok {
+			break
+		}
+		titles = append(titles, c.Title)
+		id = ParentID(c)
+	}
+	slices.Reverse(titles)
+	return titles
+}
+
+// Path returns the slash-separated path of a collection, such as "Work/Go"
+func (x *Index) Path(id int) string {
+	return strings.Join(x.Titles(id), "/")
+}
+
+// Resolver resolves Refs for one account, listing its collections at most
+// once and only when a reference is not a system collection
+type Resolver struct {
+	client *api.Client
+	index  *Index
+}
+
+// NewResolver creates a resolver for the client's account
+func NewResolver(client *api.Client) *Resolver {
+	return &Resolver{client: client}
+}
+
+// Index returns the account's collections
+func (r *Resolver) Index(ctx context.Context) (*Index, error) {
+	if r.index == nil {
+		index, err := Load(ctx, r.client)
+		if err != nil {
+			return nil, err
+		}
+		r.index = index
+	}
+	return r.index, nil
+}
+
+// Resolve returns the ID ref refers to; the empty Ref resolves to 0.
+// A number is an ID, unless it is only the title of a collection, such as
+// "2024"; numbers that are neither are taken as IDs, as the collection may
+// not be listed, and an ID that is also another collection's title is
+// ambiguous.
+func (r *Resolver) Resolve(ctx context.Context, ref Ref) (int, error) {
+	s := strings.TrimSpace(string(ref))
+	if s == "" {
+		return 0, nil
+	}
+	id, err := strconv.Atoi(s)
+	numeric := err == nil
+	if numeric && id <= All {
+		return id, nil
+	}
+	if id, ok := special[strings.ToLower(s)]; ok {
+		return id, nil
+	}
+
+	index, err := r.Index(ctx)
+	if err != nil {
+		return 0, err
+	}
+	matches := index.match(s)
+	if numeric {
+		_, known := index.Get(id)
+		others := slices.DeleteFunc(slices.Clone(matches), func(m int) bool { return m == id })
+		switch {
+		case len(others) == 0:
+			return id, nil
+		case known:
+			return 0, fmt.Errorf("collection %q is ambiguous: it is the ID of %s and the title of %s; use a full path starting with /",
+				s, index.describe([]int{id}), index.describe(others))
+		}
+	}
+	switch len(matches) {
+	case 1:
+		return matches[0], nil
+	case 0:
+		if suggestions := index.similar(s); len(suggestions) > 0 {
+			return 0, fmt.Errorf("no collection matches %q; did you mean %s?", s, index.describe(suggestions))
+		}
+		return 0, fmt.Errorf("no collection matches %q; see list-collections for the available ones", s)
+	}
+	return 0, fmt.Errorf("collection %q is ambiguous: it matches %s; use the ID or a full path starting with /", s, index.describe(matches))
+}
+
+// ResolveAll resolves several references
+func (r *Resolver) ResolveAll(ctx context.Context, refs []Ref) ([]int, error) {
+	ids := make([]int, len(refs))
+	for i, ref := range refs {
+		id, err := r.Resolve(ctx, ref)
+		if err != nil {
+			return nil, err
+		}
+		ids[i] = id
+	}
+	return ids, nil
+}
+
+// match finds the collections whose title is s, or whose path ends with the
+// path s. A leading slash anchors the path at the root. Case is ignored.
+func (x *Index) match(s string) []int {
+	anchored := strings.HasPrefix(s, "/")
+	want := splitPath(s)
+
+	var ids []int
+	for _, c := range x.Collections {
+		titles := x.Titles(c.FullID)
+		for i := range titles {
+			titles[i] = normalize(titles[i])
+		}
+		full := slices.Equal(titles, want)
+		suffix := !anchored && len(titles) > len(want) && slices.Equal(titles[len(titles)-len(want):], want)
+		if full || suffix || normalize(c.Title) == normalize(s) {
+			ids = append(ids, c.FullID)
+		}
+	}
+	return ids
+}
+
+// similar finds collections whose title contains the last part of s
+func (x *Index) similar(s string) []int {
+	parts := splitPath(s)
+	if len(parts) == 0 {
+		return nil
+	}
+	last := parts[len(parts)-1]
+
+	var ids []int
+	for _, c := range x.Collections {
+		if strings.Contains(normalize(c.Title), last) {
+			ids = append(ids, c.FullID)
+		}
+	}
+	return ids
+}
+
+// describe lists collections by path and ID for error messages
+func (x *Index) describe(ids []int) string {
+	var parts []string
+	for _, id := range ids[:min(len(ids), maxSuggestions)] {
+		parts = append(parts, fmt.Sprintf("%q (ID %d)", "/"+x.Path(id), id))
+	}
+	if len(ids) > maxSuggestions {
+		parts = append(parts, fmt.Sprintf("and %d more", len(ids)-maxSuggestions))
+	}
+	return strings.Join(parts, ", ")
+}
+
+func splitPath(s string) []string {
+	var parts []string
+	for _, p := range strings.Split(strings.Trim(s, "/ "), "/") {
+		parts = append(parts, normalize(p))
+	}
+	return parts
+}
+
+func normalize(s string) string {
+	return strings.ToLower(strings.TrimSpace(s))
+}
@@ -0,0 +1,114 @@
+package collections
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"raindrop-mcp/types"
+)
+
+func testResolver() *Resolver {
+	child := func(id int, title string, parent int) types.Collection {
+		return types.Collection{FullID: id, Title: title, Parent: &types.Parent{ID: parent}}
+	}
+	return &Resolver{index: NewIndex([]types.Collection{
+		{FullID: 5, Title: "Work"},
+		{FullID: 6, Title: "Reading"},
+		{FullID: 10, Title: "Personal"},
+		{FullID: 12, Title: "2024"},
+		{FullID: 14, Title: "6"},
+		child(7, "Go", 5),
+		child(8, "Deep", 7),
+		child(11, "Go", 10),
+	})}
+}
+
+func TestResolve(t *testing.T) {
+	tests := []struct {
+		ref     Ref
+		want    int
+		wantErr string
+	}{
+		{ref: "", want: 0},
+		{ref: "0", want: All},
+		{ref: "-1", want: Unsorted},
+		{ref: "-99", want: Trash},
+		{ref: "unsorted", want: Unsorted},
+		{ref: "Trash", want: Trash},
+
+		// IDs
+		{ref: "5", want: 5},
+		{ref: "8", want: 8},
+		{ref: "12345", want: 12345}, // unknown IDs may be unlisted collections
+
+		// Titles
+		{ref: "work", want: 5},
+		{ref: " Reading ", want: 6},
+		{ref: "Deep", want: 8},
+		{ref: "2024", want: 12}, // a number that is only a title
+		{ref: "Go", wantErr: "ambiguous"},
+
+		// Paths
+		{ref: "Work/Go", want: 7},
+		{ref: "/Personal/Go", want: 11},
+		{ref: "Go/Deep", want: 8},
+		{ref: "/Work/Go/Deep", want: 8},
+		{ref: "/Go", wantErr: "no collection matches"},
+		{ref: "Work/Nope", wantErr: "no collection matches"},
+
+		// An ID that is also another collection's title
+		{ref: "6", wantErr: `it is the ID of "/Reading" (ID 6) and the title of "/6" (ID 14)`},
+
+		{ref: "Redaing", wantErr: "see list-collections"},
+		{ref: "Dee", wantErr: `did you mean "/Work/Go/Deep" (ID 8)?`},
+	}
+	for _, tt := range tests {
+		t.Run(string(tt.ref), func(t *testing.T) {
+			got, err := testResolver().Resolve(context.Background(), tt.ref)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("Resolve(%q) = %d, %v; want error containing %q", tt.ref, got, err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil || got != tt.want {
+				t.Errorf("Resolve(%q) = %d, %v; want %d", tt.ref, got, err, tt.want)
+			}
+		})
+	}
+}
+
+func TestResolveAll(t *testing.T) {
+	r := testResolver()
+	ids, err := r.ResolveAll(context.Background(), []Ref{"Work", "Go/Deep", "unsorted"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []int{5, 8, Unsorted}; len(ids) != len(want) || ids[0] != want[0] || ids[1] != want[1] || ids[2] != want[2] {
+		t.Errorf("ResolveAll = %v, want %v", ids, want)
+	}
+	if _, err := r.ResolveAll(context.Background(), []Ref{"Work", "Go"}); err == nil {
+		t.Error("ResolveAll accepted an ambiguous reference")
+	}
+}
+
+func TestRefUnmarshalJSON(t *testing.T) {
+	tests := []struct {
+		data    string
+		want    Ref
+		wantErr bool
+	}{
+		{data: `42`, want: "42"},
+		{data: `-1`, want: "-1"},
+		{data: `"Work/Go"`, want: "Work/Go"},
+		{data: `true`, wantErr: true},
+	}
+	for _, tt := range tests {
+		var ref Ref
+		err := ref.UnmarshalJSON([]byte(tt.data))
+		if (err != nil) != tt.wantErr || ref != tt.want {
+			t.Errorf("UnmarshalJSON(%s) = %q, %v; want %q", tt.data, ref, err, tt.want)
+		}
+	}
+}
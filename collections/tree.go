@@ -0,0 +1,81 @@
+package collections
+
+import "raindrop-mcp/types"
+
+// Node is a collection in the hierarchy
+type Node struct {
+	Collection types.Collection
+	Path       string
+	// Depth is 0 for root collections
+	Depth int
+	// SubtreeCount is the number of bookmarks in the collection and all of
+	// its subcollections
+	SubtreeCount int
+	Children     []*Node
+}
+
+// Tree returns the root collections with their subcollections nested below
+// them. Children keep the order the API listed them in. A collection whose
+// parent is unknown, such as one shared from another account, is a root.
+func (x *Index) Tree() []*Node {
+	children := make(map[int][]int)
+	var roots []int
+	for _, c := range x.Collections {
+		parent := ParentID(c)
+		if _, ok := x.byID[parent]; parent == 0 || !ok {
+			roots = append(roots, c.FullID)
+			continue
+		}
+		children[parent] = append(children[parent], c.FullID)
+	}
+
+	seen := make(map[int]bool, len(x.Collections))
+	var build func(id, depth int) *Node
+	build = func(id, depth int) *Node {
+		seen[id] = true
+		c, _ := x.Get(id)
+		node := &Node{Collection: c, Path: x.Path(id), Depth: depth, SubtreeCount: c.Count}
+		for _, child := range children[id] {
+			if seen[child] {
+				continue
+			}
+			n := build(child, depth+1)
+			node.SubtreeCount += n.SubtreeCount
+			node.Children = append(node.Children, n)
+		}
+		return node
+	}
+
+	var tree []*Node
+	for _, id := range roots {
+		tree = append(tree, build(id, 0))
+	}
+	// Collections in a parent cycle are not reachable from a root
+	for _, c := range x.Collections {
+		if !seen[c.FullID] {
+			tree = append(tree, build(c.FullID, 0))
+		}
+	}
+	return tree
+}
+
+// Subtree returns the node of one collection from a tree
+func Subtree(tree []*Node, id int) (*Node, bool) {
+	for _, n := range tree {
+		if n.Collection.FullID == id {
+			return n, true
+		}
+		if found, ok := Subtree(n.Children, id); ok {
+			return found, true
+		}
+	}
+	return nil, false
+}
+
+// Walk visits the nodes of a tree depth-first, parents before children
+func Walk(tree []*Node, visit func(*Node)) {
+	for _, n := range tree {
+		visit(n)
+		Walk(n.Children, visit)
+	}
+}
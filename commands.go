@@ -0,0 +1,188 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"time"
+
+	"raindrop-mcp/auth"
+	"raindrop-mcp/config"
+)
+
+// revokeURL is where users remove app access; Raindrop.io has no token revocation API
+const revokeURL = "https://app.raindrop.io/settings/integrations"
+
+// runLogin authorizes an account and saves its credentials in the profile
+func runLogin(cfg *config.Config, args []string) error {
+	fs := flag.NewFlagSet("login", flag.ContinueOnError)
+	profileName := profileFlag(fs, cfg)
+	token := fs.String("token", "", "save a test token instead of running OAuth")
+	clientID := fs.String("client-id", "", "OAuth client ID to save in the profile")
+	clientSecret := fs.String("client-secret", "", "OAuth client secret to save in the profile")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	name := *profileName
+	if name == "" {
+		name = auth.DefaultProfile
+	}
+	profile, err := auth.CreateProfile(name)
+	if err != nil {
+		return err
+	}
+
+	// Test token: store it in the profile settings
+	if *token != "" {
+		profile.Settings.Token = *token
+		if err := profile.SaveSettings(); err != nil {
+			return err
+		}
+		return printAccount(cfg, profile.Name, *token)
+	}
+
+	if *clientID != "" || *clientSecret != "" {
+		if *clientID == "" || *clientSecret == "" {
+			return errors.New("--client-id and --client-secret must be given together")
+		}
+		profile.Settings.ClientID = *clientID
+		profile.Settings.ClientSecret = *clientSecret
+		if err := profile.SaveSettings(); err != nil {
+			return err
+		}
+	}
+
+	oauthConfig := oauthConfigFor(cfg, profile)
+	if oauthConfig == nil {
+		return errors.New("no OAuth app configured. Pass --client-id and --client-secret, set RAINDROP_CLIENT_ID + RAINDROP_CLIENT_SECRET, or use --token")
+	}
+
+	store, err := newTokenStore(cfg, profile)
+	if err != nil {
+		return err
+	}
+	if _, ok := store.(*auth.ReadOnlyStore); ok {
+		return fmt.Errorf("the token for profile %q is provided by %s and cannot be replaced by login", profile.Name, store.Location())
+	}
+
+	tokenData, err := runOAuthFlow(oauthConfig, store)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("Token saved to %s\n", store.Location())
+	return printAccount(cfg, profile.Name, tokenData.AccessToken)
+}
+
+// runLogout deletes the saved credentials of a profile
+func runLogout(cfg *config.Config, args []string) error {
+	fs := flag.NewFlagSet("logout", flag.ContinueOnError)
+	profileName := profileFlag(fs, cfg)
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	profile, err := auth.LoadProfile(*profileName)
+	if err != nil {
+		return err
+	}
+
+	if profile.Settings.Token != "" {
+		profile.Settings.Token = ""
+		if err := profile.SaveSettings(); err != nil {
+			return err
+		}
+		fmt.Printf("Removed test token from profile %q\n", profile.Name)
+	}
+
+	store, err := newTokenStore(cfg, profile)
+	if err != nil {
+		return err
+	}
+	if _, ok := store.(*auth.ReadOnlyStore); ok {
+		fmt.Printf("Token is provided by %s; remove it there\n", store.Location())
+	} else {
+		if err := store.Delete(); err != nil {
+			return err
+		}
+		fmt.Printf("Deleted saved token %s\n", store.Location())
+	}
+
+	if profile.Name == auth.DefaultProfile && cfg.Auth.Token != "" {
+		fmt.Println("Note: a token is still configured via RAINDROP_TOKEN or the config file")
+	}
+	fmt.Printf("Raindrop.io has no token revocation API. To revoke access, remove the app at %s\n", revokeURL)
+	return nil
+}
+
+// runStatus reports the token source, expiry and account of a profile
+func runStatus(cfg *config.Config, args []string) error {
+	fs := flag.NewFlagSet("status", flag.ContinueOnError)
+	profileName := profileFlag(fs, cfg)
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	src, err := findToken(cfg, *profileName)
+	if err != nil {
+		return fmt.Errorf("not logged in: %w", err)
+	}
+
+	fmt.Printf("Profile: %s\n", src.Profile.Name)
+	fmt.Printf("Source:  %s\n", src.Description)
+	if src.Token == nil {
+		fmt.Println("Token:   none saved (run 'raindrop-mcp login')")
+		return nil
+	}
+
+	if src.Token.ExpiresAt > 0 {
+		expires := time.Unix(src.Token.ExpiresAt, 0)
+		state := "valid"
+		if src.Token.IsExpired() {
+			state = "expired"
+		}
+		fmt.Printf("Expires: %s (%s)\n", expires.Format(time.RFC3339), state)
+	} else {
+		fmt.Println("Expires: never (test token)")
+	}
+
+	return printAccount(cfg, src.Profile.Name, src.Token.AccessToken)
+}
+
+// printAccount verifies a token by fetching the user it belongs to
+func printAccount(cfg *config.Config, profile, token string) error {
+	user, err := newClient(cfg, token).GetUser(context.Background())
+	if err != nil {
+		return fmt.Errorf("token for profile %q was rejected: %w", profile, err)
+	}
+
+	plan := "Free"
+	if user.Pro {
+		plan = "Pro"
+	}
+	fmt.Printf("Account: %s <%s> (%s)\n", user.FullName, user.Email, plan)
+	return nil
+}
+
+// runPrintConfig prints the effective configuration with secrets redacted
+func runPrintConfig(cfg *config.Config, path string, args []string) error {
+	fs := flag.NewFlagSet("print-config", flag.ContinueOnError)
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	if path == "" {
+		path = "none (defaults and environment only)"
+	}
+	fmt.Fprintf(os.Stderr, "Config file: %s\n", path)
+
+	data, err := json.MarshalIndent(cfg.Redacted(), "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal config: %w", err)
+	}
+	fmt.Println(string(data))
+	return nil
+}
@@ -0,0 +1,203 @@
+package completion
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+
+	"raindrop-mcp/accounts"
+	"raindrop-mcp/api"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+)
+
+// maxValues is the most completions the protocol allows in one response
+const maxValues = 100
+
+// Argument kinds that can be completed
+const (
+	kindCollection = "collection"
+	kindTag        = "tag"
+)
+
+// argumentKinds maps prompt and resource template argument names to what
+// they complete against
+var argumentKinds = map[string]string{
+	"id":         kindCollection,
+	"collection": kindCollection,
+	"tag":        kindTag,
+	"topic":      kindTag,
+}
+
+// Handler returns a completion/complete handler that completes collection
+// arguments with collection IDs (matched by title or ID) and tag arguments
+// with tag names from the request's account
+func Handler(accounts *accounts.Manager) func(context.Context, *mcp.CompleteRequest) (*mcp.CompleteResult, error) {
+	return func(ctx context.Context, req *mcp.CompleteRequest) (*mcp.CompleteResult, error) {
+		result := &mcp.CompleteResult{Completion: mcp.CompletionResultDetails{Values: []string{}}}
+		kind, ok := argumentKinds[req.Params.Argument.Name]
+		if !ok || req.Params.Ref == nil {
+			return result, nil
+		}
+		// Only the collection bookmarks template has an {id} variable
+		if req.Params.Argument.Name == "id" && req.Params.Ref.Type != "ref/resource" {
+			return result, nil
+		}
+
+		account, err := accounts.ForRequest(req)
+		if err != nil {
+			return nil, err
+		}
+
+		var candidates []candidate
+		switch kind {
+		case kindCollection:
+			candidates, err = collectionCandidates(ctx, account.Client)
+		case kindTag:
+			candidates, err = tagCandidates(ctx, account.Client)
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		values := rank(candidates, req.Params.Argument.Value)
+		result.Completion.Total = len(values)
+		if len(values) > maxValues {
+			values = values[:maxValues]
+			result.Completion.HasMore = true
+		}
+		result.Completion.Values = values
+		return result, nil
+	}
+}
+
+// candidate is a completion value and the labels it can be matched by
+type candidate struct {
+	value  string
+	labels []string
+}
+
+// collectionCandidates lists every collection, completed to its ID and
+// matched by ID or title
+func collectionCandidates(ctx context.Context, client *api.Client) ([]candidate, error) {
+	rootCollections, err := client.ListCollections(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list collections: %w", err)
+	}
+	childCollections, err := client.ListChildCollections(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list child collections: %w", err)
+	}
+
+	all := append(rootCollections.Items, childCollections.Items...)
+	candidates := make([]candidate, 0, len(all))
+	for _, c := range all {
+		id := strconv.Itoa(c.FullID)
+		candidates = append(candidates, candidate{value: id, labels: []string{c.Title, id}})
+	}
+	return candidates, nil
+}
+
+// tagCandidates lists every tag
+func tagCandidates(ctx context.Context, client *api.Client) ([]candidate, error) {
+	tags, err := client.GetTags(ctx, 0)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get tags: %w", err)
+	}
+	candidates := make([]candidate, 0, len(tags.Items))
+	for _, t := range tags.Items {
+		candidates = append(candidates, candidate{value: t.ID, labels: []string{t.ID}})
+	}
+	return candidates, nil
+}
+
+// Match scores, best first
+const (
+	scoreExact = iota
+	scorePrefix
+	scoreWordPrefix
+	scoreSubstring
+	scoreFuzzy
+	noMatch
+)
+
+// rank returns the values of the candidates matching typed, best matches
+// first. Ties keep the candidates' order.
+func rank(candidates []candidate, typed string) []string {
+	typed = strings.ToLower(strings.TrimSpace(typed))
+
+	type scored struct {
+		value string
+		score int
+	}
+	var matches []scored
+	seen := make(map[string]bool)
+	for _, c := range candidates {
+		if seen[c.value] {
+			continue
+		}
+		best := noMatch
+		for _, label := range c.labels {
+			best = min(best, score(strings.ToLower(label), typed))
+		}
+		if best != noMatch {
+			seen[c.value] = true
+			matches = append(matches, scored{c.value, best})
+		}
+	}
+	slices.SortStableFunc(matches, func(a, b scored) int { return a.score - b.score })
+
+	values := make([]string, len(matches))
+	for i, m := range matches {
+		values[i] = m.value
+	}
+	return values
+}
+
+// score rates how well label matches typed; both are lower case
+func score(label, typed string) int {
+	switch {
+	case typed == "":
+		return scorePrefix
+	case label == typed:
+		return scoreExact
+	case strings.HasPrefix(label, typed):
+		return scorePrefix
+	case wordPrefix(label, typed):
+		return scoreWordPrefix
+	case strings.Contains(label, typed):
+		return scoreSubstring
+	case subsequence(label, typed):
+		return scoreFuzzy
+	}
+	return noMatch
+}
+
+// wordPrefix reports whether a word after the first in label starts with typed
+func wordPrefix(label, typed string) bool {
+	words := strings.FieldsFunc(label, func(r rune) bool {
+		return r == ' ' || r == '-' || r == '_' || r == '/' || r == '.'
+	})
+	for _, w := range words[min(1, len(words)):] {
+		if strings.HasPrefix(w, typed) {
+			return true
+		}
+	}
+	return false
+}
+
+// subsequence reports whether the runes of typed appear in label in order
+func subsequence(label, typed string) bool {
+	rest := []rune(typed)
+	for _, r := range label {
+		if len(rest) == 0 {
+			break
+		}
+		if r == rest[0] {
+			rest = rest[1:]
+		}
+	}
+	return len(rest) == 0
+}
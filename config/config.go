@@ -0,0 +1,381 @@
+package config
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"raindrop-mcp/logging"
+	"raindrop-mcp/render"
+)
+
+// Config holds all server settings
+type Config struct {
+	// APIBaseURL is the Raindrop REST API root
+	APIBaseURL string `json:"api_base_url"`
+	// Timeout bounds each Raindrop API request
+	Timeout Duration `json:"timeout"`
+	// Profile is the account profile sessions start on
+	Profile string `json:"profile,omitempty"`
+	// DefaultCollection is used by create-bookmark when no collection is given
+	DefaultCollection int `json:"default_collection"`
+	// DefaultPerPage is used by listings when no page size is given
+	DefaultPerPage int `json:"default_perpage"`
+	// OutputFormat is the default format for listings (markdown, json, csv or compact)
+	OutputFormat string `json:"output_format"`
+
+	Tools     ToolsConfig     `json:"tools"`
+	Resources ResourcesConfig `json:"resources"`
+	Cache     CacheConfig     `json:"cache"`
+	Auth      AuthConfig      `json:"auth"`
+	HTTP      HTTPConfig      `json:"http"`
+	Log       LogConfig       `json:"log"`
+}
+
+// ToolsConfig selects which tools are registered
+type ToolsConfig struct {
+	// Enabled lists tool or category names to register; empty means all tools
+	Enabled []string `json:"enabled,omitempty"`
+	// Disabled lists tool or category names never to register
+	Disabled []string `json:"disabled,omitempty"`
+	// ReadOnly registers only tools that leave Raindrop data unchanged
+	ReadOnly bool `json:"read_only,omitempty"`
+}
+
+// ResourcesConfig controls MCP resources
+type ResourcesConfig struct {
+	// PollInterval is how often subscribed resources are checked for
+	// changes; 0 disables subscriptions
+	PollInterval Duration `json:"poll_interval"`
+}
+
+// CacheConfig controls caching of Raindrop API reads
+type CacheConfig struct {
+	Enabled bool     `json:"enabled"`
+	TTL     Duration `json:"ttl"`
+}
+
+// AuthConfig holds credentials and OAuth settings
+type AuthConfig struct {
+	Token           string `json:"token,omitempty"`
+	TokenFile       string `json:"token_file,omitempty"`
+	TokenPassphrase string `json:"token_passphrase,omitempty"`
+	ClientID        string `json:"client_id,omitempty"`
+	ClientSecret    string `json:"client_secret,omitempty"`
+	RedirectPort    int    `json:"redirect_port,omitempty"`
+	RedirectPath    string `json:"redirect_path,omitempty"`
+	// PKCE is nil when unset, which means enabled
+	PKCE *bool `json:"pkce,omitempty"`
+}
+
+// HTTPConfig controls the streamable HTTP transport (serve --http)
+type HTTPConfig struct {
+	// Addr enables HTTP mode when set, e.g. ":8080"
+	Addr string `json:"addr,omitempty"`
+	// AuthTokens are bearer tokens accepted from MCP clients
+	AuthTokens     []string `json:"auth_tokens,omitempty"`
+	TLSCert        string   `json:"tls_cert,omitempty"`
+	TLSKey         string   `json:"tls_key,omitempty"`
+	MaxSessions    int      `json:"max_sessions"`
+	SessionTimeout Duration `json:"session_timeout"`
+	// MultiUser makes each MCP client log in to its own Raindrop account
+	MultiUser bool `json:"multi_user,omitempty"`
+	// PublicURL is the externally reachable base URL, required for MultiUser
+	PublicURL string `json:"public_url,omitempty"`
+}
+
+// LogConfig controls diagnostic logging on stderr
+type LogConfig struct {
+	// Level is debug, info, warn or error
+	Level string `json:"level"`
+	// Format is text or json
+	Format string `json:"format"`
+}
+
+// minPollInterval keeps resource polling from flooding the Raindrop API
+const minPollInterval = 5 * time.Second
+
+// Duration is a time.Duration written as a string such as "30s"
+type Duration time.Duration
+
+// MarshalJSON encodes the duration as a string
+func (d Duration) MarshalJSON() ([]byte, error) {
+	return json.Marshal(time.Duration(d).String())
+}
+
+// UnmarshalJSON accepts a duration string or a number of seconds
+func (d *Duration) UnmarshalJSON(data []byte) error {
+	var s string
+	if err := json.Unmarshal(data, &s); err != nil {
+		var seconds float64
+		if err := json.Unmarshal(data, &seconds); err != nil {
+			return fmt.Errorf("duration must be a string like \"30s\" or a number of seconds")
+		}
+		*d = Duration(seconds * float64(time.Second))
+		return nil
+	}
+	parsed, err := time.ParseDuration(s)
+	if err != nil {
+		return err
+	}
+	*d = Duration(parsed)
+	return nil
+}
+
+// Default returns the built-in settings
+func Default() *Config {
+	return &Config{
+		APIBaseURL:     "https://api.raindrop.io/rest/v1",
+		Timeout:        Duration(30 * time.Second),
+		DefaultPerPage: 25,
+		OutputFormat:   render.Markdown,
+		Resources: ResourcesConfig{
+			PollInterval: Duration(time.Minute),
+		},
+		Cache: CacheConfig{
+			TTL: Duration(time.Minute),
+		},
+		HTTP: HTTPConfig{
+			MaxSessions:    100,
+			SessionTimeout: Duration(30 * time.Minute),
+		},
+		Log: LogConfig{
+			Level:  "info",
+			Format: "text",
+		},
+	}
+}
+
+// Path returns the config file location: RAINDROP_CONFIG or ~/.raindrop-mcp/config.json
+func Path() (string, error) {
+	if path := os.Getenv("RAINDROP_CONFIG"); path != "" {
+		return path, nil
+	}
+	homeDir, err := os.UserHomeDir()
+	if err != nil {
+		return "", fmt.Errorf("failed to get home directory: %w", err)
+	}
+	return filepath.Join(homeDir, ".raindrop-mcp", "config.json"), nil
+}
+
+// Load reads the config file, applies environment overrides and validates the result.
+// A missing default config file is not an error; a missing RAINDROP_CONFIG file is.
+func Load() (*Config, string, error) {
+	cfg := Default()
+
+	path, err := Path()
+	if err != nil {
+		return nil, "", err
+	}
+
+	data, err := os.ReadFile(path)
+	switch {
+	case err == nil:
+		dec := json.NewDecoder(bytes.NewReader(data))
+		dec.DisallowUnknownFields()
+		if err := dec.Decode(cfg); err != nil {
+			return nil, path, fmt.Errorf("failed to parse config file %s: %w", path, err)
+		}
+	case os.IsNotExist(err) && os.Getenv("RAINDROP_CONFIG") == "":
+		path = ""
+	default:
+		return nil, path, fmt.Errorf("failed to read config file: %w", err)
+	}
+
+	if err := cfg.applyEnv(); err != nil {
+		return nil, path, err
+	}
+	if err := cfg.Validate(); err != nil {
+		return nil, path, err
+	}
+
+	return cfg, path, nil
+}
+
+// applyEnv overrides file values with environment variables
+func (c *Config) applyEnv() error {
+	var errs []error
+
+	setString := func(name string, dst *string) {
+		if v, ok := os.LookupEnv(name); ok && v != "" {
+			*dst = v
+		}
+	}
+	setInt := func(name string, dst *int) {
+		if v, ok := os.LookupEnv(name); ok && v != "" {
+			n, err := strconv.Atoi(v)
+			if err != nil {
+				errs = append(errs, fmt.Errorf("%s: invalid integer %q", name, v))
+				return
+			}
+			*dst = n
+		}
+	}
+	setBool := func(name string, dst *bool) {
+		if v, ok := os.LookupEnv(name); ok && v != "" {
+			b, err := strconv.ParseBool(v)
+			if err != nil {
+				errs = append(errs, fmt.Errorf("%s: invalid boolean %q", name, v))
+				return
+			}
+			*dst = b
+		}
+	}
+	setDuration := func(name string, dst *Duration) {
+		if v, ok := os.LookupEnv(name); ok && v != "" {
+			d, err := time.ParseDuration(v)
+			if err != nil {
+				errs = append(errs, fmt.Errorf("%s: invalid duration %q", name, v))
+				return
+			}
+			*dst = Duration(d)
+		}
+	}
+
+	setString("RAINDROP_API_BASE_URL", &c.APIBaseURL)
+	setDuration("RAINDROP_TIMEOUT", &c.Timeout)
+	setString("RAINDROP_PROFILE", &c.Profile)
+	setInt("RAINDROP_DEFAULT_COLLECTION", &c.DefaultCollection)
+	setInt("RAINDROP_DEFAULT_PERPAGE", &c.DefaultPerPage)
+	setString("RAINDROP_OUTPUT_FORMAT", &c.OutputFormat)
+	if v := os.Getenv("RAINDROP_ENABLED_TOOLS"); v != "" {
+		c.Tools.Enabled = splitList(v)
+	}
+	if v := os.Getenv("RAINDROP_DISABLED_TOOLS"); v != "" {
+		c.Tools.Disabled = splitList(v)
+	}
+	setBool("RAINDROP_READ_ONLY", &c.Tools.ReadOnly)
+	setDuration("RAINDROP_POLL_INTERVAL", &c.Resources.PollInterval)
+	setBool("RAINDROP_CACHE", &c.Cache.Enabled)
+	setDuration("RAINDROP_CACHE_TTL", &c.Cache.TTL)
+
+	setString("RAINDROP_TOKEN", &c.Auth.Token)
+	setString("RAINDROP_TOKEN_FILE", &c.Auth.TokenFile)
+	setString("RAINDROP_TOKEN_PASSPHRASE", &c.Auth.TokenPassphrase)
+	setString("RAINDROP_CLIENT_ID", &c.Auth.ClientID)
+	setString("RAINDROP_CLIENT_SECRET", &c.Auth.ClientSecret)
+	setInt("RAINDROP_OAUTH_REDIRECT_PORT", &c.Auth.RedirectPort)
+	setString("RAINDROP_OAUTH_REDIRECT_PATH", &c.Auth.RedirectPath)
+	if v := os.Getenv("RAINDROP_OAUTH_PKCE"); v != "" {
+		var pkce bool
+		setBool("RAINDROP_OAUTH_PKCE", &pkce)
+		c.Auth.PKCE = &pkce
+	}
+
+	setString("RAINDROP_MCP_HTTP_ADDR", &c.HTTP.Addr)
+	if v := os.Getenv("RAINDROP_MCP_AUTH_TOKENS"); v != "" {
+		c.HTTP.AuthTokens = splitList(v)
+	}
+	setString("RAINDROP_MCP_TLS_CERT", &c.HTTP.TLSCert)
+	setString("RAINDROP_MCP_TLS_KEY", &c.HTTP.TLSKey)
+	setInt("RAINDROP_MCP_MAX_SESSIONS", &c.HTTP.MaxSessions)
+	setDuration("RAINDROP_MCP_SESSION_TIMEOUT", &c.HTTP.SessionTimeout)
+	setBool("RAINDROP_MCP_MULTI_USER", &c.HTTP.MultiUser)
+	setString("RAINDROP_MCP_PUBLIC_URL", &c.HTTP.PublicURL)
+
+	setString("RAINDROP_LOG_LEVEL", &c.Log.Level)
+	setString("RAINDROP_LOG_FORMAT", &c.Log.Format)
+
+	return errors.Join(errs...)
+}
+
+// Validate reports every invalid setting at once
+func (c *Config) Validate() error {
+	var errs []error
+
+	if u, err := url.Parse(c.APIBaseURL); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		errs = append(errs, fmt.Errorf("api_base_url: must be an absolute http(s) URL, got %q", c.APIBaseURL))
+	}
+	if c.Timeout <= 0 {
+		errs = append(errs, errors.New("timeout: must be positive"))
+	}
+	if c.DefaultPerPage < 1 || c.DefaultPerPage > 50 {
+		errs = append(errs, fmt.Errorf("default_perpage: must be between 1 and 50, got %d", c.DefaultPerPage))
+	}
+	if !render.ValidFormat(c.OutputFormat) {
+		errs = append(errs, fmt.Errorf("output_format: must be one of %s, got %q", strings.Join(render.Formats, ", "), c.OutputFormat))
+	}
+	if c.Resources.PollInterval != 0 && c.Resources.PollInterval < Duration(minPollInterval) {
+		errs = append(errs, fmt.Errorf("resources.poll_interval: must be 0 (disabled) or at least %s", minPollInterval))
+	}
+	if c.Cache.Enabled && c.Cache.TTL <= 0 {
+		errs = append(errs, errors.New("cache.ttl: must be positive when the cache is enabled"))
+	}
+	if c.Auth.RedirectPort < 0 || c.Auth.RedirectPort > 65535 {
+		errs = append(errs, fmt.Errorf("auth.redirect_port: must be between 0 and 65535, got %d", c.Auth.RedirectPort))
+	}
+	if (c.Auth.ClientID == "") != (c.Auth.ClientSecret == "") {
+		errs = append(errs, errors.New("auth: client_id and client_secret must be set together"))
+	}
+	if (c.HTTP.TLSCert == "") != (c.HTTP.TLSKey == "") {
+		errs = append(errs, errors.New("http: tls_cert and tls_key must be set together"))
+	}
+	if c.HTTP.MaxSessions < 0 {
+		errs = append(errs, errors.New("http.max_sessions: must not be negative"))
+	}
+	if c.HTTP.SessionTimeout < 0 {
+		errs = append(errs, errors.New("http.session_timeout: must not be negative"))
+	}
+	if c.HTTP.MultiUser {
+		if u, err := url.Parse(c.HTTP.PublicURL); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" || strings.Trim(u.Path, "/") != "" {
+			errs = append(errs, fmt.Errorf("http.public_url: multi_user needs an absolute http(s) URL without a path, got %q", c.HTTP.PublicURL))
+		}
+		if c.Auth.ClientID == "" {
+			errs = append(errs, errors.New("http.multi_user: needs auth.client_id and auth.client_secret of a Raindrop OAuth app"))
+		}
+		if len(c.HTTP.AuthTokens) > 0 {
+			errs = append(errs, errors.New("http.multi_user: cannot be combined with http.auth_tokens"))
+		}
+	}
+
+	if _, err := logging.ParseLevel(c.Log.Level); err != nil {
+		errs = append(errs, fmt.Errorf("log.level: must be debug, info, warn or error, got %q", c.Log.Level))
+	}
+	if c.Log.Format != logging.FormatText && c.Log.Format != logging.FormatJSON {
+		errs = append(errs, fmt.Errorf("log.format: must be %q or %q, got %q", logging.FormatText, logging.FormatJSON, c.Log.Format))
+	}
+
+	if len(errs) > 0 {
+		return fmt.Errorf("invalid configuration:\n%w", errors.Join(errs...))
+	}
+	return nil
+}
+
+// Redacted returns a copy with secrets masked, for display
+func (c *Config) Redacted() *Config {
+	r := *c
+	r.Tools.Enabled = append([]string(nil), c.Tools.Enabled...)
+	r.Tools.Disabled = append([]string(nil), c.Tools.Disabled...)
+	r.Auth.Token = redact(c.Auth.Token)
+	r.Auth.TokenPassphrase = redact(c.Auth.TokenPassphrase)
+	r.Auth.ClientSecret = redact(c.Auth.ClientSecret)
+	r.HTTP.AuthTokens = make([]string, len(c.HTTP.AuthTokens))
+	for i, token := range c.HTTP.AuthTokens {
+		r.HTTP.AuthTokens[i] = redact(token)
+	}
+	return &r
+}
+
+func redact(secret string) string {
+	if secret == "" {
+		return ""
+	}
+	return "[REDACTED]"
+}
+
+func splitList(s string) []string {
+	var items []string
+	for _, item := range strings.Split(s, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
@@ -0,0 +1,188 @@
+package httpserver
+
+import (
+	"context"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net"
+	"net/http"
+	"time"
+
+	"github.com/modelcontextprotocol/go-sdk/auth"
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+)
+
+// MCPPath is where the streamable HTTP endpoint is mounted
+const MCPPath = "/mcp"
+
+// sessionIDHeader identifies an existing MCP session
+const sessionIDHeader = "Mcp-Session-Id"
+
+// Options configures the HTTP server
+type Options struct {
+	// Addr is the listen address, e.g. ":8080"
+	Addr string
+	// AuthTokens are the bearer tokens MCP clients may use.
+	// Without tokens the server only listens on loopback addresses.
+	AuthTokens []string
+	// TLSCertFile and TLSKeyFile enable HTTPS when both are set
+	TLSCertFile string
+	TLSKeyFile  string
+	// MaxSessions caps concurrent MCP sessions (0 for no limit)
+	MaxSessions int
+	// SessionTimeout closes sessions idle for this long (0 keeps them open)
+	SessionTimeout time.Duration
+	// Provider, when set, authenticates MCP clients via OAuth and gives each
+	// user their own Raindrop identity. It replaces AuthTokens.
+	Provider *Provider
+}
+
+// Handler returns the HTTP handler serving server at MCPPath plus /healthz
+func Handler(server *mcp.Server, opts Options) http.Handler {
+	var mcpHandler http.Handler = mcp.NewStreamableHTTPHandler(func(*http.Request) *mcp.Server {
+		return server
+	}, &mcp.StreamableHTTPOptions{SessionTimeout: opts.SessionTimeout})
+
+	mux := http.NewServeMux()
+
+	mcpHandler = limitSessions(server, opts.MaxSessions, mcpHandler)
+	switch {
+	case opts.Provider != nil:
+		opts.Provider.register(mux)
+		mcpHandler = auth.RequireBearerToken(opts.Provider.Verify, &auth.RequireBearerTokenOptions{
+			ResourceMetadataURL: opts.Provider.resourceMetadataURL(),
+		})(mcpHandler)
+	case len(opts.AuthTokens) > 0:
+		mcpHandler = auth.RequireBearerToken(staticTokenVerifier(opts.AuthTokens), nil)(mcpHandler)
+	}
+
+	mux.Handle(MCPPath, mcpHandler)
+	mux.HandleFunc("GET /healthz", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(map[string]any{
+			"status":   "ok",
+			"sessions": countSessions(server),
+		})
+	})
+	return mux
+}
+
+// ListenAndServe serves server over streamable HTTP until ctx is cancelled
+func ListenAndServe(ctx context.Context, server *mcp.Server, opts Options) error {
+	if err := checkOptions(opts); err != nil {
+		return err
+	}
+
+	httpServer := &http.Server{
+		Addr:              opts.Addr,
+		Handler:           Handler(server, opts),
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+
+	errChan := make(chan error, 1)
+	go func() {
+		if opts.TLSCertFile != "" {
+			slog.Info("Listening", "url", "https://"+opts.Addr+MCPPath)
+			errChan <- httpServer.ListenAndServeTLS(opts.TLSCertFile, opts.TLSKeyFile)
+		} else {
+			slog.Info("Listening", "url", "http://"+opts.Addr+MCPPath)
+			errChan <- httpServer.ListenAndServe()
+		}
+	}()
+
+	select {
+	case err := <-errChan:
+		return err
+	case <-ctx.Done():
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+		if err := httpServer.Shutdown(shutdownCtx); err != nil {
+			return err
+		}
+		if err := <-errChan; !errors.Is(err, http.ErrServerClosed) {
+			return err
+		}
+		return nil
+	}
+}
+
+// checkOptions rejects unsafe or incomplete settings
+func checkOptions(opts Options) error {
+	if (opts.TLSCertFile == "") != (opts.TLSKeyFile == "") {
+		return errors.New("TLS needs both a certificate and a key file")
+	}
+	if opts.Provider != nil && len(opts.AuthTokens) > 0 {
+		return errors.New("bearer tokens cannot be combined with multi-user OAuth")
+	}
+	if opts.Provider == nil && len(opts.AuthTokens) == 0 && !isLoopback(opts.Addr) {
+		return fmt.Errorf("refusing to serve on %q without bearer tokens; configure http.auth_tokens or listen on 127.0.0.1", opts.Addr)
+	}
+	for _, token := range opts.AuthTokens {
+		if len(token) < 16 {
+			return errors.New("bearer tokens must be at least 16 characters")
+		}
+	}
+	return nil
+}
+
+// isLoopback reports whether addr only listens on the local machine
+func isLoopback(addr string) bool {
+	host, _, err := net.SplitHostPort(addr)
+	if err != nil {
+		return false
+	}
+	if host == "localhost" {
+		return true
+	}
+	ip := net.ParseIP(host)
+	return ip != nil && ip.IsLoopback()
+}
+
+// staticTokenVerifier accepts any of the configured tokens. Each token becomes
+// its own user, so sessions cannot be taken over with a different token.
+func staticTokenVerifier(tokens []string) auth.TokenVerifier {
+	hashes := make([][32]byte, len(tokens))
+	for i, t := range tokens {
+		hashes[i] = sha256.Sum256([]byte(t))
+	}
+
+	return func(ctx context.Context, token string, req *http.Request) (*auth.TokenInfo, error) {
+		sum := sha256.Sum256([]byte(token))
+		for i, h := range hashes {
+			if subtle.ConstantTimeCompare(sum[:], h[:]) == 1 {
+				return &auth.TokenInfo{
+					UserID:     fmt.Sprintf("client-%d", i+1),
+					Expiration: time.Now().Add(time.Hour),
+				}, nil
+			}
+		}
+		return nil, auth.ErrInvalidToken
+	}
+}
+
+// limitSessions rejects new sessions once max sessions are open
+func limitSessions(server *mcp.Server, max int, next http.Handler) http.Handler {
+	if max <= 0 {
+		return next
+	}
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodPost && r.Header.Get(sessionIDHeader) == "" && countSessions(server) >= max {
+			w.Header().Set("Retry-After", "30")
+			http.Error(w, "too many sessions", http.StatusServiceUnavailable)
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+func countSessions(server *mcp.Server) int {
+	n := 0
+	for range server.Sessions() {
+		n++
+	}
+	return n
+}
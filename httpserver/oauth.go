@@ -0,0 +1,662 @@
+package httpserver
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"html"
+	"log/slog"
+	"net"
+	"net/http"
+	"net/url"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"raindrop-mcp/accounts"
+	"raindrop-mcp/api"
+	"raindrop-mcp/auth"
+	"raindrop-mcp/logging"
+
+	sdkauth "github.com/modelcontextprotocol/go-sdk/auth"
+	"github.com/modelcontextprotocol/go-sdk/oauthex"
+)
+
+const (
+	callbackPath = "/oauth/callback"
+
+	accessTokenTTL  = time.Hour
+	refreshTokenTTL = 30 * 24 * time.Hour
+	codeTTL         = 2 * time.Minute
+	pendingTTL      = 10 * time.Minute
+	clientTTL       = 24 * time.Hour
+
+	// Registration is unauthenticated, so the number of clients and the
+	// size of each is bounded
+	maxClients      = 1000
+	maxRedirectURIs = 10
+	maxClientName   = 200
+)
+
+// Provider is an OAuth 2.1 authorization server for MCP clients that
+// delegates user login to Raindrop.io. Each access token it issues maps to the
+// Raindrop token of the user who authorized it, so every MCP session acts as
+// its own Raindrop user. State is kept in memory; after a restart clients
+// simply authorize again.
+type Provider struct {
+	issuer        string
+	raindrop      auth.OAuthConfig
+	clientOptions api.Options
+
+	mu       sync.Mutex
+	clients  map[string]*registeredClient
+	pending  map[string]*pendingAuthorization
+	codes    map[string]*authorizationCode
+	access   map[string]*grant
+	refresh  map[string]*grant
+	users    map[int]*raindropUser
+	sweptAt  time.Time
+	nowFunc  func() time.Time
+	randFunc func(int) (string, error)
+}
+
+// registeredClient is an MCP client registered via dynamic client registration
+type registeredClient struct {
+	ID           string   `json:"client_id"`
+	Name         string   `json:"client_name,omitempty"`
+	RedirectURIs []string `json:"redirect_uris"`
+
+	registered time.Time
+}
+
+// pendingAuthorization tracks a user sent to Raindrop to log in
+type pendingAuthorization struct {
+	clientID      string
+	redirectURI   string
+	clientState   string
+	codeChallenge string
+	verifier      string // our PKCE verifier for the Raindrop leg
+	expires       time.Time
+}
+
+// authorizationCode is issued to the MCP client after Raindrop login
+type authorizationCode struct {
+	clientID      string
+	redirectURI   string
+	codeChallenge string
+	user          *raindropUser
+	expires       time.Time
+}
+
+// grant ties an issued token to a Raindrop user
+type grant struct {
+	clientID string
+	user     *raindropUser
+	expires  time.Time
+}
+
+// raindropUser holds one user's Raindrop token, refreshed on demand
+type raindropUser struct {
+	id int
+
+	mu    sync.Mutex
+	token *auth.TokenData
+}
+
+// NewProvider creates a provider reachable at issuer (the server's public URL).
+// raindrop is the Raindrop OAuth app; issuer + /oauth/callback must be
+// registered as its redirect URI. clientOptions configures per-user API clients.
+func NewProvider(issuer string, raindrop auth.OAuthConfig, clientOptions api.Options) (*Provider, error) {
+	u, err := url.Parse(issuer)
+	if err != nil || (u.Scheme != "https" && u.Scheme != "http") || u.Host == "" || strings.Trim(u.Path, "/") != "" {
+		return nil, fmt.Errorf("public URL must be an absolute http(s) URL without a path, got %q", issuer)
+	}
+	if raindrop.ClientID == "" || raindrop.ClientSecret == "" {
+		return nil, errors.New("multi-user mode needs a Raindrop OAuth client ID and secret")
+	}
+
+	issuer = strings.TrimRight(issuer, "/")
+	raindrop.RedirectURI = issuer + callbackPath
+
+	return &Provider{
+		issuer:        issuer,
+		raindrop:      raindrop,
+		clientOptions: clientOptions,
+		clients:       make(map[string]*registeredClient),
+		pending:       make(map[string]*pendingAuthorization),
+		codes:         make(map[string]*authorizationCode),
+		access:        make(map[string]*grant),
+		refresh:       make(map[string]*grant),
+		users:         make(map[int]*raindropUser),
+		nowFunc:       time.Now,
+		randFunc:      randomToken,
+	}, nil
+}
+
+// RedirectURI is the callback to register with the Raindrop OAuth app
+func (p *Provider) RedirectURI() string {
+	return p.raindrop.RedirectURI
+}
+
+// resourceMetadataURL is the RFC 9728 metadata location for the MCP endpoint
+func (p *Provider) resourceMetadataURL() string {
+	return p.issuer + "/.well-known/oauth-protected-resource" + MCPPath
+}
+
+// register mounts the OAuth endpoints on mux
+func (p *Provider) register(mux *http.ServeMux) {
+	metadata := sdkauth.ProtectedResourceMetadataHandler(&oauthex.ProtectedResourceMetadata{
+		Resource:               p.issuer + MCPPath,
+		AuthorizationServers:   []string{p.issuer},
+		BearerMethodsSupported: []string{"header"},
+		ResourceName:           "Raindrop MCP",
+	})
+	mux.Handle("/.well-known/oauth-protected-resource", metadata)
+	mux.Handle("/.well-known/oauth-protected-resource"+MCPPath, metadata)
+	mux.HandleFunc("GET /.well-known/oauth-authorization-server", p.serveMetadata)
+	mux.HandleFunc("POST /register", p.serveRegister)
+	mux.HandleFunc("GET /authorize", p.serveAuthorize)
+	mux.HandleFunc("GET "+callbackPath, p.serveCallback)
+	mux.HandleFunc("POST /token", p.serveToken)
+}
+
+// serveMetadata serves RFC 8414 authorization server metadata
+func (p *Provider) serveMetadata(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Access-Control-Allow-Origin", "*")
+	writeJSON(w, http.StatusOK, map[string]any{
+		"issuer":                                p.issuer,
+		"authorization_endpoint":                p.issuer + "/authorize",
+		"token_endpoint":                        p.issuer + "/token",
+		"registration_endpoint":                 p.issuer + "/register",
+		"response_types_supported":              []string{"code"},
+		"grant_types_supported":                 []string{"authorization_code", "refresh_token"},
+		"code_challenge_methods_supported":      []string{"S256"},
+		"token_endpoint_auth_methods_supported": []string{"none"},
+	})
+}
+
+// serveRegister implements RFC 7591 dynamic client registration for public clients
+func (p *Provider) serveRegister(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		ClientName   string   `json:"client_name"`
+		RedirectURIs []string `json:"redirect_uris"`
+	}
+	if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, 64*1024)).Decode(&req); err != nil {
+		writeOAuthError(w, http.StatusBadRequest, "invalid_client_metadata", "malformed registration request")
+		return
+	}
+	if len(req.RedirectURIs) == 0 {
+		writeOAuthError(w, http.StatusBadRequest, "invalid_redirect_uri", "redirect_uris is required")
+		return
+	}
+	if len(req.RedirectURIs) > maxRedirectURIs {
+		writeOAuthError(w, http.StatusBadRequest, "invalid_redirect_uri", fmt.Sprintf("at most %d redirect_uris are allowed", maxRedirectURIs))
+		return
+	}
+	if len(req.ClientName) > maxClientName {
+		writeOAuthError(w, http.StatusBadRequest, "invalid_client_metadata", fmt.Sprintf("client_name must be at most %d bytes", maxClientName))
+		return
+	}
+	for _, uri := range req.RedirectURIs {
+		if err := checkRedirectURI(uri); err != nil {
+			writeOAuthError(w, http.StatusBadRequest, "invalid_redirect_uri", err.Error())
+			return
+		}
+	}
+
+	id, err := p.randFunc(16)
+	if err != nil {
+		writeOAuthError(w, http.StatusInternalServerError, "server_error", "failed to generate client ID")
+		return
+	}
+	client := &registeredClient{ID: id, Name: req.ClientName, RedirectURIs: req.RedirectURIs}
+
+	p.mu.Lock()
+	p.sweepLocked()
+	if len(p.clients) >= maxClients {
+		p.mu.Unlock()
+		slog.Warn("Rejected OAuth client registration: too many clients", "clients", maxClients)
+		writeOAuthError(w, http.StatusServiceUnavailable, "temporarily_unavailable", "too many registered clients, try again later")
+		return
+	}
+	client.registered = p.nowFunc()
+	p.clients[id] = client
+	p.mu.Unlock()
+	slog.Info("Registered OAuth client", "client", id, "name", client.Name)
+
+	writeJSON(w, http.StatusCreated, map[string]any{
+		"client_id":                  client.ID,
+		"client_name":                client.Name,
+		"redirect_uris":              client.RedirectURIs,
+		"grant_types":                []string{"authorization_code", "refresh_token"},
+		"response_types":             []string{"code"},
+		"token_endpoint_auth_method": "none",
+	})
+}
+
+// serveAuthorize validates the MCP client's request and sends the user to Raindrop
+func (p *Provider) serveAuthorize(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	clientID, redirectURI := q.Get("client_id"), q.Get("redirect_uri")
+
+	p.mu.Lock()
+	client := p.clients[clientID]
+	p.mu.Unlock()
+
+	// Never redirect to an unverified URI
+	if client == nil {
+		writeErrorPage(w, http.StatusBadRequest, "Unknown client_id")
+		return
+	}
+	if !slices.Contains(client.RedirectURIs, redirectURI) {
+		writeErrorPage(w, http.StatusBadRequest, "redirect_uri is not registered for this client")
+		return
+	}
+
+	clientState := q.Get("state")
+	fail := func(code, description string) {
+		redirectWithParams(w, r, redirectURI, url.Values{"error": {code}, "error_description": {description}, "state": {clientState}})
+	}
+
+	if q.Get("response_type") != "code" {
+		fail("unsupported_response_type", "only response_type=code is supported")
+		return
+	}
+	challenge := q.Get("code_challenge")
+	if challenge == "" || q.Get("code_challenge_method") != "S256" {
+		fail("invalid_request", "PKCE with code_challenge_method=S256 is required")
+		return
+	}
+
+	state, err := auth.GenerateState()
+	if err != nil {
+		fail("server_error", "failed to generate state")
+		return
+	}
+	var verifier, raindropChallenge string
+	if !p.raindrop.DisablePKCE {
+		verifier, raindropChallenge, err = auth.GeneratePKCE()
+		if err != nil {
+			fail("server_error", "failed to generate PKCE verifier")
+			return
+		}
+	}
+
+	p.mu.Lock()
+	p.sweepLocked()
+	p.pending[state] = &pendingAuthorization{
+		clientID:      clientID,
+		redirectURI:   redirectURI,
+		clientState:   clientState,
+		codeChallenge: challenge,
+		verifier:      verifier,
+		expires:       p.nowFunc().Add(pendingTTL),
+	}
+	p.mu.Unlock()
+
+	http.Redirect(w, r, auth.AuthorizationURL(&p.raindrop, state, raindropChallenge), http.StatusFound)
+}
+
+// serveCallback completes the Raindrop login and returns a code to the MCP client
+func (p *Provider) serveCallback(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+
+	// Each state is single-use
+	p.mu.Lock()
+	pending := p.pending[q.Get("state")]
+	delete(p.pending, q.Get("state"))
+	p.mu.Unlock()
+
+	if pending == nil || p.nowFunc().After(pending.expires) {
+		writeErrorPage(w, http.StatusBadRequest, "Invalid or expired authorization state")
+		return
+	}
+
+	fail := func(code, description string) {
+		redirectWithParams(w, r, pending.redirectURI, url.Values{"error": {code}, "error_description": {description}, "state": {pending.clientState}})
+	}
+
+	if errParam := q.Get("error"); errParam != "" {
+		fail("access_denied", "Raindrop authorization denied: "+errParam)
+		return
+	}
+
+	token, err := auth.ExchangeCode(&p.raindrop, q.Get("code"), pending.verifier)
+	if err != nil {
+		slog.Warn("Raindrop code exchange failed", "client", pending.clientID, "error", err)
+		fail("server_error", "failed to exchange Raindrop authorization code")
+		return
+	}
+
+	user, err := p.userFor(r.Context(), token)
+	if err != nil {
+		slog.Warn("Failed to identify Raindrop user", "client", pending.clientID, "error", err)
+		fail("server_error", "failed to identify Raindrop user")
+		return
+	}
+
+	code, err := p.randFunc(32)
+	if err != nil {
+		fail("server_error", "failed to generate authorization code")
+		return
+	}
+
+	logging.AddSecret(code)
+	p.mu.Lock()
+	p.codes[code] = &authorizationCode{
+		clientID:      pending.clientID,
+		redirectURI:   pending.redirectURI,
+		codeChallenge: pending.codeChallenge,
+		user:          user,
+		expires:       p.nowFunc().Add(codeTTL),
+	}
+	p.mu.Unlock()
+
+	slog.Info("User authorized", "user", user.id, "client", pending.clientID)
+	redirectWithParams(w, r, pending.redirectURI, url.Values{"code": {code}, "state": {pending.clientState}})
+}
+
+// userFor records the Raindrop token under the user it belongs to
+func (p *Provider) userFor(ctx context.Context, token *auth.TokenData) (*raindropUser, error) {
+	opts := p.clientOptions
+	opts.TokenSource = nil
+	info, err := api.NewClientWithOptions(token.AccessToken, opts).GetUser(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	user, ok := p.users[info.ID]
+	if !ok {
+		user = &raindropUser{id: info.ID}
+		p.users[info.ID] = user
+	}
+	user.mu.Lock()
+	user.setTokenLocked(token)
+	user.mu.Unlock()
+
+	return user, nil
+}
+
+// serveToken implements the authorization_code and refresh_token grants
+func (p *Provider) serveToken(w http.ResponseWriter, r *http.Request) {
+	r.Body = http.MaxBytesReader(w, r.Body, 64*1024)
+	if err := r.ParseForm(); err != nil {
+		writeOAuthError(w, http.StatusBadRequest, "invalid_request", "malformed form body")
+		return
+	}
+
+	var g *grant
+	switch r.PostForm.Get("grant_type") {
+	case "authorization_code":
+		p.mu.Lock()
+		code := p.codes[r.PostForm.Get("code")]
+		delete(p.codes, r.PostForm.Get("code")) // single-use
+		p.mu.Unlock()
+		if code != nil {
+			logging.RemoveSecret(r.PostForm.Get("code"))
+		}
+
+		switch {
+		case code == nil || p.nowFunc().After(code.expires):
+			writeOAuthError(w, http.StatusBadRequest, "invalid_grant", "unknown or expired code")
+			return
+		case code.clientID != r.PostForm.Get("client_id") || code.redirectURI != r.PostForm.Get("redirect_uri"):
+			writeOAuthError(w, http.StatusBadRequest, "invalid_grant", "client_id or redirect_uri mismatch")
+			return
+		case subtle.ConstantTimeCompare([]byte(auth.PKCEChallenge(r.PostForm.Get("code_verifier"))), []byte(code.codeChallenge)) != 1:
+			writeOAuthError(w, http.StatusBadRequest, "invalid_grant", "PKCE verification failed")
+			return
+		}
+		g = &grant{clientID: code.clientID, user: code.user}
+
+	case "refresh_token":
+		p.mu.Lock()
+		old := p.refresh[r.PostForm.Get("refresh_token")]
+		delete(p.refresh, r.PostForm.Get("refresh_token")) // rotate
+		p.mu.Unlock()
+		if old != nil {
+			logging.RemoveSecret(r.PostForm.Get("refresh_token"))
+		}
+
+		if old == nil || p.nowFunc().After(old.expires) {
+			writeOAuthError(w, http.StatusBadRequest, "invalid_grant", "unknown or expired refresh token")
+			return
+		}
+		if id := r.PostForm.Get("client_id"); id != "" && id != old.clientID {
+			writeOAuthError(w, http.StatusBadRequest, "invalid_grant", "client_id mismatch")
+			return
+		}
+		g = &grant{clientID: old.clientID, user: old.user}
+
+	default:
+		writeOAuthError(w, http.StatusBadRequest, "unsupported_grant_type", "grant_type must be authorization_code or refresh_token")
+		return
+	}
+
+	accessToken, err := p.randFunc(32)
+	if err != nil {
+		writeOAuthError(w, http.StatusInternalServerError, "server_error", "failed to generate token")
+		return
+	}
+	refreshToken, err := p.randFunc(32)
+	if err != nil {
+		writeOAuthError(w, http.StatusInternalServerError, "server_error", "failed to generate token")
+		return
+	}
+
+	logging.AddSecret(accessToken, refreshToken)
+	now := p.nowFunc()
+	p.mu.Lock()
+	p.sweepLocked()
+	p.access[accessToken] = &grant{clientID: g.clientID, user: g.user, expires: now.Add(accessTokenTTL)}
+	p.refresh[refreshToken] = &grant{clientID: g.clientID, user: g.user, expires: now.Add(refreshTokenTTL)}
+	p.mu.Unlock()
+
+	w.Header().Set("Cache-Control", "no-store")
+	writeJSON(w, http.StatusOK, map[string]any{
+		"access_token":  accessToken,
+		"token_type":    "Bearer",
+		"expires_in":    int(accessTokenTTL.Seconds()),
+		"refresh_token": refreshToken,
+	})
+}
+
+// Verify is the bearer token verifier for the MCP endpoint
+func (p *Provider) Verify(_ context.Context, token string, _ *http.Request) (*sdkauth.TokenInfo, error) {
+	p.mu.Lock()
+	g := p.access[token]
+	p.mu.Unlock()
+
+	if g == nil || p.nowFunc().After(g.expires) {
+		return nil, sdkauth.ErrInvalidToken
+	}
+
+	return &sdkauth.TokenInfo{
+		UserID:     fmt.Sprintf("raindrop-%d", g.user.id),
+		Expiration: g.expires,
+		Extra:      map[string]any{"raindrop_user_id": g.user.id},
+	}, nil
+}
+
+// Account builds an isolated account for the user behind a verified token.
+// It is the accounts.UserResolver in multi-user mode.
+func (p *Provider) Account(info *sdkauth.TokenInfo) (*accounts.Account, error) {
+	id, _ := info.Extra["raindrop_user_id"].(int)
+
+	p.mu.Lock()
+	user := p.users[id]
+	p.mu.Unlock()
+	if user == nil {
+		return nil, errors.New("unknown Raindrop user; authorize again")
+	}
+
+	opts := p.clientOptions
+	opts.TokenSource = func() (string, error) { return p.accessToken(user) }
+
+	return &accounts.Account{
+		Profile: fmt.Sprintf("raindrop user %d", user.id),
+		Client:  api.NewClientWithOptions("", opts),
+	}, nil
+}
+
+// accessToken returns the user's Raindrop token, refreshing it when expired
+func (p *Provider) accessToken(user *raindropUser) (string, error) {
+	user.mu.Lock()
+	defer user.mu.Unlock()
+
+	if user.token.RefreshToken != "" && user.token.IsExpired() {
+		token, err := auth.RefreshToken(&p.raindrop, user.token.RefreshToken)
+		if err != nil {
+			slog.Warn("Failed to refresh Raindrop token", "user", user.id, "error", err)
+			return "", fmt.Errorf("failed to refresh Raindrop token: %w", err)
+		}
+		user.setTokenLocked(token)
+	}
+	return user.token.AccessToken, nil
+}
+
+// setTokenLocked replaces the user's Raindrop token, keeping the refresh
+// token when a refresh response leaves it out. The new values are redacted
+// from logs and the replaced ones forgotten. user.mu must be held.
+func (user *raindropUser) setTokenLocked(token *auth.TokenData) {
+	old := user.token
+	if old != nil && token.RefreshToken == "" {
+		token.RefreshToken = old.RefreshToken
+	}
+	logging.AddSecret(token.AccessToken, token.RefreshToken)
+	if old != nil {
+		if old.AccessToken != token.AccessToken {
+			logging.RemoveSecret(old.AccessToken)
+		}
+		if old.RefreshToken != token.RefreshToken {
+			logging.RemoveSecret(old.RefreshToken)
+		}
+	}
+	user.token = token
+}
+
+// sweepLocked drops expired state at most once a minute. p.mu must be held.
+func (p *Provider) sweepLocked() {
+	now := p.nowFunc()
+	if now.Sub(p.sweptAt) < time.Minute {
+		return
+	}
+	p.sweptAt = now
+
+	for k, v := range p.pending {
+		if now.After(v.expires) {
+			delete(p.pending, k)
+		}
+	}
+	for k, v := range p.codes {
+		if now.After(v.expires) {
+			delete(p.codes, k)
+			logging.RemoveSecret(k)
+		}
+	}
+	for k, v := range p.access {
+		if now.After(v.expires) {
+			delete(p.access, k)
+			logging.RemoveSecret(k)
+		}
+	}
+	for k, v := range p.refresh {
+		if now.After(v.expires) {
+			delete(p.refresh, k)
+			logging.RemoveSecret(k)
+		}
+	}
+
+	// Clients that have held no authorization or token for a day are
+	// dropped; they register again when they next need to
+	inUse := make(map[string]bool)
+	for _, v := range p.pending {
+		inUse[v.clientID] = true
+	}
+	for _, v := range p.codes {
+		inUse[v.clientID] = true
+	}
+	for _, v := range p.access {
+		inUse[v.clientID] = true
+	}
+	for _, v := range p.refresh {
+		inUse[v.clientID] = true
+	}
+	for k, v := range p.clients {
+		if !inUse[k] && now.Sub(v.registered) > clientTTL {
+			delete(p.clients, k)
+		}
+	}
+}
+
+// checkRedirectURI allows https, loopback http and private-use app schemes
+func checkRedirectURI(raw string) error {
+	u, err := url.Parse(raw)
+	if err != nil || u.Scheme == "" || u.Fragment != "" {
+		return fmt.Errorf("invalid redirect URI %q", raw)
+	}
+	switch strings.ToLower(u.Scheme) {
+	case "https":
+		return nil
+	case "http":
+		host := u.Hostname()
+		if ip := net.ParseIP(host); host == "localhost" || (ip != nil && ip.IsLoopback()) {
+			return nil
+		}
+		return fmt.Errorf("http redirect URIs must use a loopback host: %q", raw)
+	case "javascript", "data", "file", "vbscript":
+		return fmt.Errorf("redirect URI scheme not allowed: %q", raw)
+	default:
+		return nil // native app scheme
+	}
+}
+
+// redirectWithParams redirects to base with params added to its query
+func redirectWithParams(w http.ResponseWriter, r *http.Request, base string, params url.Values) {
+	u, err := url.Parse(base)
+	if err != nil {
+		writeErrorPage(w, http.StatusBadRequest, "Invalid redirect URI")
+		return
+	}
+	q := u.Query()
+	for k, vs := range params {
+		if len(vs) > 0 && vs[0] != "" {
+			q.Set(k, vs[0])
+		}
+	}
+	u.RawQuery = q.Encode()
+	http.Redirect(w, r, u.String(), http.StatusFound)
+}
+
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
+
+func writeOAuthError(w http.ResponseWriter, status int, code, description string) {
+	w.Header().Set("Cache-Control", "no-store")
+	writeJSON(w, status, map[string]string{"error": code, "error_description": description})
+}
+
+func writeErrorPage(w http.ResponseWriter, status int, message string) {
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.WriteHeader(status)
+	fmt.Fprintf(w, "<html><body><h1>Authorization Error</h1><p>%s</p></body></html>", html.EscapeString(message))
+}
+
+// randomToken returns n random bytes as unpadded base64url
+func randomToken(n int) (string, error) {
+	buf := make([]byte, n)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(buf), nil
+}
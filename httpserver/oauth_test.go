@@ -0,0 +1,338 @@
+package httpserver
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"raindrop-mcp/api"
+	"raindrop-mcp/auth"
+	"raindrop-mcp/logging"
+)
+
+const testRedirectURI = "http://127.0.0.1:9999/callback"
+
+func newTestProvider(t *testing.T) (*Provider, *http.ServeMux) {
+	t.Helper()
+	p, err := NewProvider("https://mcp.example.com", auth.OAuthConfig{ClientID: "id", ClientSecret: "secret"}, api.Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	now := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	p.nowFunc = func() time.Time { return now }
+	mux := http.NewServeMux()
+	p.register(mux)
+	return p, mux
+}
+
+// registerClient registers a client with testRedirectURI and returns its ID
+func registerClient(t *testing.T, mux *http.ServeMux) string {
+	t.Helper()
+	rec := httptest.NewRecorder()
+	mux.ServeHTTP(rec, httptest.NewRequest("POST", "/register", strings.NewReader(`{"client_name":"test","redirect_uris":["`+testRedirectURI+`"]}`)))
+	if rec.Code != http.StatusCreated {
+		t.Fatalf("register: status %d: %s", rec.Code, rec.Body)
+	}
+	var resp struct {
+		ClientID string `json:"client_id"`
+	}
+	if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil {
+		t.Fatal(err)
+	}
+	return resp.ClientID
+}
+
+// redirectQuery returns the query of a redirect response
+func redirectQuery(t *testing.T, rec *httptest.ResponseRecorder) url.Values {
+	t.Helper()
+	if rec.Code != http.StatusFound {
+		t.Fatalf("status %d, want a redirect: %s", rec.Code, rec.Body)
+	}
+	u, err := url.Parse(rec.Header().Get("Location"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return u.Query()
+}
+
+func TestAuthorize(t *testing.T) {
+	p, mux := newTestProvider(t)
+	clientID := registerClient(t, mux)
+
+	tests := []struct {
+		name   string
+		params url.Values
+		// status is set when the request must fail without a redirect
+		status int
+		// wantError is the error redirected to the client, if any
+		wantError string
+	}{
+		{
+			name:   "unknown client",
+			params: url.Values{"client_id": {"nope"}, "redirect_uri": {testRedirectURI}},
+			status: http.StatusBadRequest,
+		},
+		{
+			name:   "unregistered redirect URI",
+			params: url.Values{"client_id": {clientID}, "redirect_uri": {"https://evil.example.com/"}},
+			status: http.StatusBadRequest,
+		},
+		{
+			name:      "missing PKCE",
+			params:    url.Values{"client_id": {clientID}, "redirect_uri": {testRedirectURI}, "response_type": {"code"}, "state": {"xyz"}},
+			wantError: "invalid_request",
+		},
+		{
+			name: "plain PKCE",
+			params: url.Values{"client_id": {clientID}, "redirect_uri": {testRedirectURI}, "response_type": {"code"}, "state": {"xyz"},
+				"code_challenge": {"abc"}, "code_challenge_method": {"plain"}},
+			wantError: "invalid_request",
+		},
+		{
+			name: "token response type",
+			params: url.Values{"client_id": {clientID}, "redirect_uri": {testRedirectURI}, "response_type": {"token"}, "state": {"xyz"},
+				"code_challenge": {"abc"}, "code_challenge_method": {"S256"}},
+			wantError: "unsupported_response_type",
+		},
+		{
+			name: "valid",
+			params: url.Values{"client_id": {clientID}, "redirect_uri": {testRedirectURI}, "response_type": {"code"}, "state": {"xyz"},
+				"code_challenge": {"abc"}, "code_challenge_method": {"S256"}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			mux.ServeHTTP(rec, httptest.NewRequest("GET", "/authorize?"+tt.params.Encode(), nil))
+			if tt.status != 0 {
+				if rec.Code != tt.status {
+					t.Errorf("status %d, want %d", rec.Code, tt.status)
+				}
+				return
+			}
+
+			q := redirectQuery(t, rec)
+			if tt.wantError != "" {
+				if q.Get("error") != tt.wantError || q.Get("state") != "xyz" {
+					t.Errorf("redirected with %v, want error %q and the client state", q, tt.wantError)
+				}
+				return
+			}
+
+			// The user is sent to Raindrop with a state of our own
+			state := q.Get("state")
+			if state == "" || state == "xyz" {
+				t.Fatalf("Raindrop state = %q, want a fresh state", state)
+			}
+			pending := p.pending[state]
+			if pending == nil || pending.clientState != "xyz" || pending.codeChallenge != "abc" {
+				t.Errorf("pending authorization = %+v", pending)
+			}
+		})
+	}
+}
+
+func TestCallbackState(t *testing.T) {
+	p, mux := newTestProvider(t)
+	p.pending["good"] = &pendingAuthorization{clientID: "c", redirectURI: testRedirectURI, clientState: "xyz", expires: p.nowFunc().Add(time.Minute)}
+	p.pending["expired"] = &pendingAuthorization{clientID: "c", redirectURI: testRedirectURI, clientState: "xyz", expires: p.nowFunc().Add(-time.Minute)}
+
+	tests := []struct {
+		name   string
+		query  string
+		status int
+	}{
+		{name: "missing state", query: "code=abc", status: http.StatusBadRequest},
+		{name: "unknown state", query: "state=bad&code=abc", status: http.StatusBadRequest},
+		{name: "expired state", query: "state=expired&code=abc", status: http.StatusBadRequest},
+		{name: "denied", query: "state=good&error=access_denied", status: http.StatusFound},
+		{name: "state reused", query: "state=good&error=access_denied", status: http.StatusBadRequest},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			mux.ServeHTTP(rec, httptest.NewRequest("GET", callbackPath+"?"+tt.query, nil))
+			if rec.Code != tt.status {
+				t.Fatalf("status %d, want %d: %s", rec.Code, tt.status, rec.Body)
+			}
+			if rec.Code == http.StatusFound {
+				if q := redirectQuery(t, rec); q.Get("error") != "access_denied" || q.Get("state") != "xyz" {
+					t.Errorf("redirected with %v, want access_denied and the client state", q)
+				}
+			}
+		})
+	}
+}
+
+func TestTokenPKCE(t *testing.T) {
+	verifier := "dBjftJeZ4CVP-mB92K27uhbUJU1p1r_wW1gFWFOEjXk"
+	challenge := auth.PKCEChallenge(verifier)
+
+	tests := []struct {
+		name     string
+		code     *authorizationCode
+		form     url.Values
+		wantCode int
+		wantErr  string
+	}{
+		{
+			name:     "valid",
+			form:     url.Values{"client_id": {"c"}, "redirect_uri": {testRedirectURI}, "code_verifier": {verifier}},
+			wantCode: http.StatusOK,
+		},
+		{
+			name:     "wrong verifier",
+			form:     url.Values{"client_id": {"c"}, "redirect_uri": {testRedirectURI}, "code_verifier": {"wrong"}},
+			wantCode: http.StatusBadRequest,
+			wantErr:  "PKCE verification failed",
+		},
+		{
+			name:     "missing verifier",
+			form:     url.Values{"client_id": {"c"}, "redirect_uri": {testRedirectURI}},
+			wantCode: http.StatusBadRequest,
+			wantErr:  "PKCE verification failed",
+		},
+		{
+			name:     "other client",
+			form:     url.Values{"client_id": {"other"}, "redirect_uri": {testRedirectURI}, "code_verifier": {verifier}},
+			wantCode: http.StatusBadRequest,
+			wantErr:  "mismatch",
+		},
+		{
+			name:     "other redirect URI",
+			form:     url.Values{"client_id": {"c"}, "redirect_uri": {"http://127.0.0.1:1/"}, "code_verifier": {verifier}},
+			wantCode: http.StatusBadRequest,
+			wantErr:  "mismatch",
+		},
+		{
+			name:     "expired code",
+			code:     &authorizationCode{clientID: "c", redirectURI: testRedirectURI, codeChallenge: challenge, expires: time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)},
+			form:     url.Values{"client_id": {"c"}, "redirect_uri": {testRedirectURI}, "code_verifier": {verifier}},
+			wantCode: http.StatusBadRequest,
+			wantErr:  "unknown or expired code",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p, mux := newTestProvider(t)
+			code := tt.code
+			if code == nil {
+				code = &authorizationCode{clientID: "c", redirectURI: testRedirectURI, codeChallenge: challenge,
+					user: &raindropUser{id: 1}, expires: p.nowFunc().Add(codeTTL)}
+			}
+			p.codes["the-code"] = code
+
+			form := tt.form
+			form.Set("grant_type", "authorization_code")
+			form.Set("code", "the-code")
+			exchange := func() *httptest.ResponseRecorder {
+				req := httptest.NewRequest("POST", "/token", strings.NewReader(form.Encode()))
+				req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+				rec := httptest.NewRecorder()
+				mux.ServeHTTP(rec, req)
+				return rec
+			}
+
+			rec := exchange()
+			if rec.Code != tt.wantCode || !strings.Contains(rec.Body.String(), tt.wantErr) {
+				t.Fatalf("status %d: %s; want %d with %q", rec.Code, rec.Body, tt.wantCode, tt.wantErr)
+			}
+			if rec.Code == http.StatusOK {
+				var tokens struct {
+					AccessToken  string `json:"access_token"`
+					RefreshToken string `json:"refresh_token"`
+				}
+				if err := json.Unmarshal(rec.Body.Bytes(), &tokens); err != nil {
+					t.Fatal(err)
+				}
+				if got := logging.Redact(tokens.AccessToken + " " + tokens.RefreshToken); strings.Contains(got, tokens.AccessToken) || strings.Contains(got, tokens.RefreshToken) {
+					t.Errorf("issued tokens are not redacted from logs: %s", got)
+				}
+			}
+			// Codes are single-use, whether or not the exchange succeeded
+			if rec := exchange(); rec.Code != http.StatusBadRequest {
+				t.Errorf("second exchange: status %d, want %d", rec.Code, http.StatusBadRequest)
+			}
+		})
+	}
+}
+
+func TestRegisterLimits(t *testing.T) {
+	p, mux := newTestProvider(t)
+	register := func(body string) int {
+		rec := httptest.NewRecorder()
+		mux.ServeHTTP(rec, httptest.NewRequest("POST", "/register", strings.NewReader(body)))
+		return rec.Code
+	}
+
+	uris := make([]string, maxRedirectURIs+1)
+	for i := range uris {
+		uris[i] = fmt.Sprintf("%q", fmt.Sprintf("https://example.com/%d", i))
+	}
+	if code := register(`{"redirect_uris":[` + strings.Join(uris, ",") + `]}`); code != http.StatusBadRequest {
+		t.Errorf("too many redirect URIs: status %d, want %d", code, http.StatusBadRequest)
+	}
+	if code := register(`{"client_name":"` + strings.Repeat("x", maxClientName+1) + `","redirect_uris":["https://example.com/"]}`); code != http.StatusBadRequest {
+		t.Errorf("long client name: status %d, want %d", code, http.StatusBadRequest)
+	}
+
+	for range maxClients {
+		registerClient(t, mux)
+	}
+	if code := register(`{"redirect_uris":["https://example.com/"]}`); code != http.StatusServiceUnavailable {
+		t.Errorf("registration beyond the cap: status %d, want %d", code, http.StatusServiceUnavailable)
+	}
+
+	// Clients without tokens expire, one holding a token is kept
+	var kept string
+	for id := range p.clients {
+		kept = id
+		break
+	}
+	p.refresh["token"] = &grant{clientID: kept, user: &raindropUser{id: 1}, expires: p.nowFunc().Add(refreshTokenTTL)}
+	later := p.nowFunc().Add(clientTTL + time.Minute)
+	p.nowFunc = func() time.Time { return later }
+
+	registerClient(t, mux)
+	if len(p.clients) != 2 || p.clients[kept] == nil {
+		t.Errorf("%d clients after expiry, want the client with a token and the new one", len(p.clients))
+	}
+}
+
+func TestSetToken(t *testing.T) {
+	user := &raindropUser{id: 1}
+	user.setTokenLocked(&auth.TokenData{AccessToken: "first-access-token", RefreshToken: "first-refresh-token"})
+	if got := logging.Redact("first-access-token first-refresh-token"); strings.Contains(got, "first") {
+		t.Errorf("Raindrop tokens are not redacted: %s", got)
+	}
+
+	// A refresh response without a refresh token keeps the old one
+	user.setTokenLocked(&auth.TokenData{AccessToken: "second-access-token"})
+	if user.token.RefreshToken != "first-refresh-token" {
+		t.Errorf("refresh token = %q, want the previous one kept", user.token.RefreshToken)
+	}
+	if got := logging.Redact("first-access-token second-access-token first-refresh-token"); got != "first-access-token [REDACTED] [REDACTED]" {
+		t.Errorf("Redact = %q, want only the replaced access token left", got)
+	}
+}
+
+func TestSweepForgetsExpiredTokens(t *testing.T) {
+	p, _ := newTestProvider(t)
+	logging.AddSecret("expired-access-token")
+	p.access["expired-access-token"] = &grant{clientID: "c", user: &raindropUser{id: 1}, expires: p.nowFunc().Add(-time.Minute)}
+
+	p.mu.Lock()
+	p.sweepLocked()
+	p.mu.Unlock()
+	if _, ok := p.access["expired-access-token"]; ok {
+		t.Fatal("expired access token was not swept")
+	}
+	if got := logging.Redact("expired-access-token"); got != "expired-access-token" {
+		t.Errorf("Redact = %q, want the swept token forgotten", got)
+	}
+}
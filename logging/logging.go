@@ -0,0 +1,187 @@
+package logging
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"sync"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+)
+
+// Log formats
+const (
+	FormatText = "text"
+	FormatJSON = "json"
+)
+
+// Options configures the process logger
+type Options struct {
+	// Level is debug, info, warn or error
+	Level string
+	// Format is text or json
+	Format string
+}
+
+// New returns a logger that writes to w and forwards records to the MCP
+// sessions attached to the returned Forwarder. Secrets are redacted before a
+// record reaches either.
+func New(w io.Writer, opts Options) (*slog.Logger, *Forwarder, error) {
+	level, err := ParseLevel(opts.Level)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	handlerOpts := &slog.HandlerOptions{Level: level}
+	var base slog.Handler
+	switch opts.Format {
+	case "", FormatText:
+		base = slog.NewTextHandler(w, handlerOpts)
+	case FormatJSON:
+		base = slog.NewJSONHandler(w, handlerOpts)
+	default:
+		return nil, nil, fmt.Errorf("unknown log format %q", opts.Format)
+	}
+
+	fwd := &Forwarder{sessions: make(map[*mcp.ServerSession]*mcp.LoggingHandler)}
+	return slog.New(&handler{base: base, fwd: fwd}), fwd, nil
+}
+
+// ParseLevel parses a level name; empty means info
+func ParseLevel(s string) (slog.Level, error) {
+	var level slog.Level
+	if s == "" {
+		return slog.LevelInfo, nil
+	}
+	if err := level.UnmarshalText([]byte(s)); err != nil {
+		return 0, fmt.Errorf("unknown log level %q", s)
+	}
+	return level, nil
+}
+
+// Forwarder sends log records to connected MCP clients as logging
+// notifications, at the level each client requested with logging/setLevel.
+type Forwarder struct {
+	mu        sync.RWMutex
+	sessions  map[*mcp.ServerSession]*mcp.LoggingHandler
+	broadcast bool
+}
+
+// Attach starts forwarding records to ss
+func (f *Forwarder) Attach(ss *mcp.ServerSession) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.sessions[ss] = mcp.NewLoggingHandler(ss, &mcp.LoggingHandlerOptions{LoggerName: "raindrop-mcp"})
+}
+
+// Detach stops forwarding records to ss
+func (f *Forwarder) Detach(ss *mcp.ServerSession) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	delete(f.sessions, ss)
+}
+
+// SetBroadcast controls records logged outside a session (see WithSession).
+// When enabled they go to every attached session; otherwise they stay local.
+// Multi-user servers keep this off so users never see each other's activity.
+func (f *Forwarder) SetBroadcast(broadcast bool) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.broadcast = broadcast
+}
+
+// targets returns the session handlers a record logged with ctx goes to
+func (f *Forwarder) targets(ctx context.Context) []slog.Handler {
+	f.mu.RLock()
+	defer f.mu.RUnlock()
+
+	if ss := sessionFrom(ctx); ss != nil {
+		if h, ok := f.sessions[ss]; ok {
+			return []slog.Handler{h}
+		}
+		return nil
+	}
+	if !f.broadcast {
+		return nil
+	}
+	handlers := make([]slog.Handler, 0, len(f.sessions))
+	for _, h := range f.sessions {
+		handlers = append(handlers, h)
+	}
+	return handlers
+}
+
+type sessionKey struct{}
+
+// WithSession marks ctx as belonging to ss, so records logged with it are
+// forwarded to that session only
+func WithSession(ctx context.Context, ss *mcp.ServerSession) context.Context {
+	if ss == nil {
+		return ctx
+	}
+	return context.WithValue(ctx, sessionKey{}, ss)
+}
+
+func sessionFrom(ctx context.Context) *mcp.ServerSession {
+	if ctx == nil {
+		return nil
+	}
+	ss, _ := ctx.Value(sessionKey{}).(*mcp.ServerSession)
+	return ss
+}
+
+// handler redacts records and fans them out to the base handler and sessions.
+// Session handlers are created per session, so attributes and groups added
+// with WithAttrs/WithGroup are recorded and replayed onto them.
+type handler struct {
+	base slog.Handler
+	fwd  *Forwarder
+	ops  []func(slog.Handler) slog.Handler
+}
+
+func (h *handler) Enabled(ctx context.Context, level slog.Level) bool {
+	if h.base.Enabled(ctx, level) {
+		return true
+	}
+	for _, t := range h.fwd.targets(ctx) {
+		if t.Enabled(ctx, level) {
+			return true
+		}
+	}
+	return false
+}
+
+func (h *handler) Handle(ctx context.Context, r slog.Record) error {
+	r = redactRecord(r)
+
+	var err error
+	if h.base.Enabled(ctx, r.Level) {
+		err = h.base.Handle(ctx, r)
+	}
+	for _, t := range h.fwd.targets(ctx) {
+		for _, op := range h.ops {
+			t = op(t)
+		}
+		if t.Enabled(ctx, r.Level) {
+			// A session that just closed is not worth reporting
+			_ = t.Handle(ctx, r.Clone())
+		}
+	}
+	return err
+}
+
+func (h *handler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	attrs = redactAttrs(attrs)
+	return h.with(h.base.WithAttrs(attrs), func(t slog.Handler) slog.Handler { return t.WithAttrs(attrs) })
+}
+
+func (h *handler) WithGroup(name string) slog.Handler {
+	return h.with(h.base.WithGroup(name), func(t slog.Handler) slog.Handler { return t.WithGroup(name) })
+}
+
+func (h *handler) with(base slog.Handler, op func(slog.Handler) slog.Handler) *handler {
+	ops := make([]func(slog.Handler) slog.Handler, len(h.ops), len(h.ops)+1)
+	copy(ops, h.ops)
+	return &handler{base: base, fwd: h.fwd, ops: append(ops, op)}
+}
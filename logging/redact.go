@@ -0,0 +1,104 @@
+package logging
+
+import (
+	"log/slog"
+	"regexp"
+	"strings"
+	"sync"
+)
+
+// redacted replaces secret values in log output
+const redacted = "[REDACTED]"
+
+// sensitiveKeys are attribute key fragments whose values are never logged
+var sensitiveKeys = []string{"token", "secret", "passphrase", "password", "authorization", "code_verifier", "api_key"}
+
+// bearerPattern catches credentials embedded in header dumps and error text
+var bearerPattern = regexp.MustCompile(`(?i)\bbearer\s+[A-Za-z0-9._~+/=-]+`)
+
+var (
+	secretsMu sync.RWMutex
+	secrets   = map[string]struct{}{}
+)
+
+// AddSecret registers values that must never appear in log output, such as
+// the API token or OAuth client secret. Very short values are ignored.
+func AddSecret(values ...string) {
+	secretsMu.Lock()
+	defer secretsMu.Unlock()
+	for _, v := range values {
+		if len(v) >= 8 {
+			secrets[v] = struct{}{}
+		}
+	}
+}
+
+// RemoveSecret forgets values registered with AddSecret, once they are
+// revoked or expired
+func RemoveSecret(values ...string) {
+	secretsMu.Lock()
+	defer secretsMu.Unlock()
+	for _, v := range values {
+		delete(secrets, v)
+	}
+}
+
+// Redact removes registered secrets and bearer credentials from s
+func Redact(s string) string {
+	secretsMu.RLock()
+	for secret := range secrets {
+		if strings.Contains(s, secret) {
+			s = strings.ReplaceAll(s, secret, redacted)
+		}
+	}
+	secretsMu.RUnlock()
+	return bearerPattern.ReplaceAllString(s, "Bearer "+redacted)
+}
+
+// isSensitiveKey reports whether an attribute key names a credential
+func isSensitiveKey(key string) bool {
+	key = strings.ToLower(key)
+	for _, fragment := range sensitiveKeys {
+		if strings.Contains(key, fragment) {
+			return true
+		}
+	}
+	return false
+}
+
+// redactRecord returns a copy of r with its message and attributes redacted
+func redactRecord(r slog.Record) slog.Record {
+	out := slog.NewRecord(r.Time, r.Level, Redact(r.Message), r.PC)
+	r.Attrs(func(a slog.Attr) bool {
+		out.AddAttrs(redactAttr(a))
+		return true
+	})
+	return out
+}
+
+func redactAttrs(attrs []slog.Attr) []slog.Attr {
+	out := make([]slog.Attr, len(attrs))
+	for i, a := range attrs {
+		out[i] = redactAttr(a)
+	}
+	return out
+}
+
+func redactAttr(a slog.Attr) slog.Attr {
+	if isSensitiveKey(a.Key) {
+		return slog.String(a.Key, redacted)
+	}
+
+	v := a.Value.Resolve()
+	switch v.Kind() {
+	case slog.KindString:
+		return slog.String(a.Key, Redact(v.String()))
+	case slog.KindGroup:
+		return slog.Attr{Key: a.Key, Value: slog.GroupValue(redactAttrs(v.Group())...)}
+	case slog.KindAny:
+		if err, ok := v.Any().(error); ok {
+			return slog.String(a.Key, Redact(err.Error()))
+		}
+	}
+	return slog.Attr{Key: a.Key, Value: v}
+}
@@ -2,123 +2,261 @@ package main
 
 import (
 	"context"
+	"errors"
+	"flag"
 	"fmt"
-	"log"
+	"log/slog"
 	"os"
+	"os/signal"
+	"syscall"
+	"time"
 
+	"raindrop-mcp/accounts"
 	"raindrop-mcp/api"
 	"raindrop-mcp/auth"
+	"raindrop-mcp/completion"
+	"raindrop-mcp/config"
+	"raindrop-mcp/httpserver"
+	"raindrop-mcp/logging"
+	"raindrop-mcp/policy"
+	"raindrop-mcp/prompts"
 	"raindrop-mcp/resources"
 	"raindrop-mcp/tools"
 
 	"github.com/modelcontextprotocol/go-sdk/mcp"
 )
 
+const version = "2.0.0"
+
+const usage = `Usage: raindrop-mcp [command] [flags]
+
+Commands:
+  serve    Run the MCP server on stdio (default), or over HTTP with --http
+  login    Authorize an account and save its token
+  logout   Delete the saved token for an account
+  status   Show where the token comes from and which account it belongs to
+  print-config
+           Show the effective configuration with secrets redacted
+  help     Show this help
+
+Run 'raindrop-mcp <command> -h' for command flags.
+
+Settings are read from ~/.raindrop-mcp/config.json (or RAINDROP_CONFIG),
+with environment variables taking precedence.
+`
+
 func main() {
-	// Get API token from available sources
-	token, err := getAccessToken()
-	if err != nil {
-		log.Fatalf("Failed to get access token: %v", err)
+	command, args := "serve", os.Args[1:]
+	if len(args) > 0 && args[0] != "" && args[0][0] != '-' {
+		command, args = args[0], args[1:]
 	}
 
-	// Create Raindrop API client
-	client := api.NewClient(token)
+	if command == "help" {
+		fmt.Print(usage)
+		return
+	}
 
-	// Create MCP server
-	server := mcp.NewServer(
-		&mcp.Implementation{
-			Name:    "raindrop-mcp",
-			Version: "2.0.0",
-		},
-		nil,
-	)
+	cfg, cfgPath, err := config.Load()
+	if err != nil {
+		slog.Error("Failed to load configuration", "error", err)
+		os.Exit(1)
+	}
 
-	// Register all tools
-	tools.RegisterTools(server, client)
-	tools.RegisterExtendedTools(server, client)
+	logger, forwarder, err := logging.New(os.Stderr, logging.Options{Level: cfg.Log.Level, Format: cfg.Log.Format})
+	if err != nil {
+		slog.Error("Failed to set up logging", "error", err)
+		os.Exit(1)
+	}
+	slog.SetDefault(logger)
+	logging.AddSecret(cfg.Auth.Token, cfg.Auth.TokenPassphrase, cfg.Auth.ClientSecret)
+	logging.AddSecret(cfg.HTTP.AuthTokens...)
 
-	// Register resources
-	resources.RegisterResources(server, client)
+	switch command {
+	case "serve":
+		err = runServe(cfg, forwarder, args)
+	case "login":
+		err = runLogin(cfg, args)
+	case "logout":
+		err = runLogout(cfg, args)
+	case "status":
+		err = runStatus(cfg, args)
+	case "print-config":
+		err = runPrintConfig(cfg, cfgPath, args)
+	default:
+		fmt.Fprintf(os.Stderr, "Unknown command %q\n\n%s", command, usage)
+		os.Exit(2)
+	}
 
-	// Run server on stdio transport
-	fmt.Fprintln(os.Stderr, "Raindrop MCP Server v2.0.0 starting...")
-	fmt.Fprintln(os.Stderr, "Loaded 20 tools, 4 resources")
-	if err := server.Run(context.Background(), &mcp.StdioTransport{}); err != nil {
-		log.Fatalf("Server error: %v", err)
+	if err != nil {
+		if err != flag.ErrHelp {
+			slog.Error("Command failed", "command", command, "error", err)
+		}
+		os.Exit(1)
 	}
 }
 
-// getAccessToken retrieves access token from available sources
-// Priority:
-// 1. RAINDROP_TOKEN environment variable (test token)
-// 2. OAuth2 flow using CLIENT_ID and CLIENT_SECRET
-// 3. Previously saved OAuth token
-func getAccessToken() (string, error) {
-	// Priority 1: Direct token from environment
-	if token := os.Getenv("RAINDROP_TOKEN"); token != "" {
-		fmt.Fprintln(os.Stderr, "Using token from RAINDROP_TOKEN environment variable")
-		return token, nil
+// runServe runs the MCP server on stdio, or on streamable HTTP with --http
+func runServe(cfg *config.Config, forwarder *logging.Forwarder, args []string) error {
+	fs := flag.NewFlagSet("serve", flag.ContinueOnError)
+	profile := profileFlag(fs, cfg)
+	httpAddr := fs.String("http", cfg.HTTP.Addr, "serve streamable HTTP on this address (e.g. :8080) instead of stdio")
+	tlsCert := fs.String("tls-cert", cfg.HTTP.TLSCert, "TLS certificate file for --http")
+	tlsKey := fs.String("tls-key", cfg.HTTP.TLSKey, "TLS key file for --http")
+	maxSessions := fs.Int("max-sessions", cfg.HTTP.MaxSessions, "maximum concurrent HTTP sessions (0 for no limit)")
+	readOnly := fs.Bool("read-only", cfg.Tools.ReadOnly, "register only tools that do not change Raindrop data")
+	if err := fs.Parse(args); err != nil {
+		return err
 	}
+	cfg.Tools.ReadOnly = *readOnly
 
-	// Get OAuth credentials
-	clientID := os.Getenv("RAINDROP_CLIENT_ID")
-	clientSecret := os.Getenv("RAINDROP_CLIENT_SECRET")
+	// Sessions start on the selected profile and may switch later
+	accountManager := accounts.NewManager(*profile, func(profile string, interactive bool) (*api.Client, error) {
+		token, err := getAccessToken(cfg, profile, interactive)
+		if err != nil {
+			return nil, err
+		}
+		return newClient(cfg, token), nil
+	})
 
-	// Priority 2/3: OAuth flow
-	if clientID != "" && clientSecret != "" {
-		return getOAuthToken(clientID, clientSecret)
+	// In multi-user mode every HTTP client logs in to its own Raindrop account
+	var provider *httpserver.Provider
+	if cfg.HTTP.MultiUser {
+		if *httpAddr == "" {
+			return errors.New("multi-user mode requires --http")
+		}
+		var err error
+		provider, err = httpserver.NewProvider(cfg.HTTP.PublicURL, auth.OAuthConfig{
+			ClientID:     cfg.Auth.ClientID,
+			ClientSecret: cfg.Auth.ClientSecret,
+			DisablePKCE:  cfg.Auth.PKCE != nil && !*cfg.Auth.PKCE,
+		}, clientOptions(cfg))
+		if err != nil {
+			return err
+		}
+		accountManager.SetUserResolver(provider.Account)
+		slog.Info("Multi-user mode enabled", "redirect_uri", provider.RedirectURI())
+	} else if _, err := accountManager.Account(accountManager.DefaultProfile()); err != nil {
+		// Open the starting account up front so auth problems surface at startup
+		return fmt.Errorf("failed to get access token: %w", err)
 	}
 
-	return "", fmt.Errorf("no authentication configured. Set RAINDROP_TOKEN or RAINDROP_CLIENT_ID + RAINDROP_CLIENT_SECRET")
-}
+	// Records outside a session reach every client unless users must stay isolated
+	forwarder.SetBroadcast(!accountManager.MultiUser())
 
-// getOAuthToken handles OAuth token retrieval and refresh
-func getOAuthToken(clientID, clientSecret string) (string, error) {
-	config := &auth.OAuthConfig{
-		ClientID:     clientID,
-		ClientSecret: clientSecret,
-	}
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
 
-	// Try to load existing token
-	savedToken, err := auth.LoadToken()
+	server, counts, err := newServer(ctx, cfg, accountManager, forwarder)
 	if err != nil {
-		fmt.Fprintf(os.Stderr, "Warning: failed to load saved token: %v\n", err)
-	}
-
-	// If we have a valid saved token
-	if savedToken != nil && savedToken.IsValid() {
-		// Check if token needs refresh
-		if savedToken.IsExpired() {
-			fmt.Fprintln(os.Stderr, "Access token expired, refreshing...")
-			newToken, err := auth.RefreshToken(config, savedToken.RefreshToken)
-			if err != nil {
-				fmt.Fprintf(os.Stderr, "Failed to refresh token: %v. Starting new OAuth flow...\n", err)
-				// Fall through to new OAuth flow
-			} else {
-				if err := auth.SaveToken(newToken); err != nil {
-					fmt.Fprintf(os.Stderr, "Warning: failed to save refreshed token: %v\n", err)
+		return err
+	}
+
+	slog.Info("Raindrop MCP Server starting", "version", version, "tools", counts.tools, "resources", counts.resources, "prompts", counts.prompts)
+
+	// Run server on streamable HTTP
+	if *httpAddr != "" {
+		return httpserver.ListenAndServe(ctx, server, httpserver.Options{
+			Addr:           *httpAddr,
+			AuthTokens:     cfg.HTTP.AuthTokens,
+			TLSCertFile:    *tlsCert,
+			TLSKeyFile:     *tlsKey,
+			MaxSessions:    *maxSessions,
+			SessionTimeout: time.Duration(cfg.HTTP.SessionTimeout),
+			Provider:       provider,
+		})
+	}
+
+	// Run server on stdio transport
+	if err := server.Run(ctx, &mcp.StdioTransport{}); err != nil && ctx.Err() == nil {
+		return fmt.Errorf("server error: %w", err)
+	}
+	return nil
+}
+
+// serverCounts reports how many tools, resources and prompts newServer registered
+type serverCounts struct {
+	tools, resources, prompts int
+}
+
+// newServer creates the MCP server with all tools, resources and prompts
+// registered. Resource polling runs until ctx is done.
+func newServer(ctx context.Context, cfg *config.Config, accountManager *accounts.Manager, forwarder *logging.Forwarder) (*mcp.Server, serverCounts, error) {
+	// The watcher needs the server, so handlers reach it through this variable
+	var watcher *resources.Watcher
+	opts := &mcp.ServerOptions{
+		// Forward logs to the session and release its state once it ends
+		InitializedHandler: func(ctx context.Context, req *mcp.InitializedRequest) {
+			forwarder.Attach(req.Session)
+			slog.Debug("Session started", "session", req.Session.ID())
+			go func() {
+				req.Session.Wait()
+				forwarder.Detach(req.Session)
+				accountManager.Forget(req.Session)
+				if watcher != nil {
+					watcher.Forget(req.Session)
 				}
-				fmt.Fprintln(os.Stderr, "Token refreshed successfully")
-				return newToken.AccessToken, nil
-			}
-		} else {
-			fmt.Fprintln(os.Stderr, "Using saved OAuth token")
-			return savedToken.AccessToken, nil
+				slog.Debug("Session ended", "session", req.Session.ID())
+			}()
+		},
+		// Complete collection IDs and tag names in prompt and resource arguments
+		CompletionHandler: completion.Handler(accountManager),
+	}
+	pollInterval := time.Duration(cfg.Resources.PollInterval)
+	if pollInterval > 0 && accountManager.MultiUser() {
+		// Updates go to every subscriber of a URI, whichever user they are
+		slog.Info("Resource subscriptions are not available in multi-user mode")
+		pollInterval = 0
+	}
+	if pollInterval > 0 {
+		opts.SubscribeHandler = func(ctx context.Context, req *mcp.SubscribeRequest) error {
+			return watcher.Subscribe(ctx, req)
+		}
+		opts.UnsubscribeHandler = func(ctx context.Context, req *mcp.UnsubscribeRequest) error {
+			return watcher.Unsubscribe(ctx, req)
 		}
 	}
+	server := mcp.NewServer(
+		&mcp.Implementation{
+			Name:    "raindrop-mcp",
+			Version: version,
+		},
+		opts,
+	)
 
-	// Start new OAuth flow
-	fmt.Fprintln(os.Stderr, "Starting OAuth authorization flow...")
-	newToken, err := auth.StartOAuthFlow(context.Background(), config)
-	if err != nil {
-		return "", fmt.Errorf("OAuth flow failed: %w", err)
+	// Register the tools allowed by the policy
+	toolPolicy := policy.New(cfg.Tools.ReadOnly, cfg.Tools.Enabled, cfg.Tools.Disabled)
+	slog.Debug("Tool policy", "policy", toolPolicy.String())
+	registry := tools.NewRegistry(server, accountManager, tools.Options{
+		DefaultCollection: cfg.DefaultCollection,
+		DefaultPerPage:    cfg.DefaultPerPage,
+		OutputFormat:      cfg.OutputFormat,
+		Policy:            toolPolicy,
+	})
+	tools.RegisterTools(registry)
+	tools.RegisterExtendedTools(registry)
+	tools.RegisterBulkTools(registry)
+	tools.RegisterSubtreeTools(registry)
+	// Profiles belong to the server owner, not to multi-user sessions
+	if !accountManager.MultiUser() {
+		tools.RegisterProfileTools(registry)
+	}
+	if err := registry.Validate(); err != nil {
+		return nil, serverCounts{}, fmt.Errorf("failed to register tools: %w", err)
 	}
 
-	// Save the new token
-	if err := auth.SaveToken(newToken); err != nil {
-		fmt.Fprintf(os.Stderr, "Warning: failed to save token: %v\n", err)
+	// Register resources and prompts, limited to what the registered tools may read
+	resourceOpts := resources.Options{Format: cfg.OutputFormat, Exposes: registry.Exposes}
+	resourceCount := resources.RegisterResources(server, accountManager, resourceOpts)
+	promptCount := prompts.RegisterPrompts(server, accountManager, registry.Exposes)
+	if pollInterval > 0 {
+		watcher = resources.NewWatcher(server, accountManager, resourceOpts, pollInterval)
+		go watcher.Run(ctx)
 	}
 
-	fmt.Fprintln(os.Stderr, "OAuth authorization successful")
-	return newToken.AccessToken, nil
+	return server, serverCounts{tools: len(registry.Registered()), resources: resourceCount, prompts: promptCount}, nil
+}
+
+// profileFlag adds the --profile flag, defaulting to the configured profile
+func profileFlag(fs *flag.FlagSet, cfg *config.Config) *string {
+	return fs.String("profile", cfg.Profile, "account profile to use (default: RAINDROP_PROFILE, config file or \"default\")")
 }
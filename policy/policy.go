@@ -0,0 +1,114 @@
+package policy
+
+import (
+	"slices"
+	"strings"
+)
+
+// Tool and resource categories
+const (
+	Bookmarks   = "bookmarks"
+	Collections = "collections"
+	Tags        = "tags"
+	Highlights  = "highlights"
+	Account     = "account"
+)
+
+// Categories lists every category, for validation and help output
+var Categories = []string{Bookmarks, Collections, Tags, Highlights, Account}
+
+// Policy decides which tools are exposed. Allow and deny entries are tool
+// names or category names; deny wins over allow.
+type Policy struct {
+	readOnly bool
+	allow    map[string]bool
+	deny     map[string]bool
+}
+
+// New creates a policy. An empty allow list allows everything not denied;
+// readOnly additionally drops every tool that changes data.
+func New(readOnly bool, allow, deny []string) *Policy {
+	p := &Policy{readOnly: readOnly, deny: toSet(deny)}
+	if len(allow) > 0 {
+		p.allow = toSet(allow)
+	}
+	return p
+}
+
+// ReadOnly reports whether only non-mutating tools are allowed
+func (p *Policy) ReadOnly() bool {
+	return p != nil && p.readOnly
+}
+
+// AllowsTool reports whether a tool may be registered.
+// readOnly states whether the tool leaves Raindrop data unchanged.
+func (p *Policy) AllowsTool(name, category string, readOnly bool) bool {
+	if p == nil {
+		return true
+	}
+	if p.deny[name] || p.deny[category] {
+		return false
+	}
+	if p.readOnly && !readOnly {
+		return false
+	}
+	return p.allow == nil || p.allow[name] || p.allow[category]
+}
+
+// Unknown returns allow and deny entries that name neither a category nor
+// one of the given tools
+func (p *Policy) Unknown(tools []string) []string {
+	if p == nil {
+		return nil
+	}
+	var unknown []string
+	for _, set := range []map[string]bool{p.allow, p.deny} {
+		for entry := range set {
+			if !slices.Contains(Categories, entry) && !slices.Contains(tools, entry) && !slices.Contains(unknown, entry) {
+				unknown = append(unknown, entry)
+			}
+		}
+	}
+	slices.Sort(unknown)
+	return unknown
+}
+
+// String summarises the policy for logs
+func (p *Policy) String() string {
+	if p == nil {
+		return "all tools"
+	}
+	var parts []string
+	if p.readOnly {
+		parts = append(parts, "read-only")
+	}
+	if p.allow != nil {
+		parts = append(parts, "allow "+joinSet(p.allow))
+	}
+	if len(p.deny) > 0 {
+		parts = append(parts, "deny "+joinSet(p.deny))
+	}
+	if len(parts) == 0 {
+		return "all tools"
+	}
+	return strings.Join(parts, "; ")
+}
+
+func toSet(entries []string) map[string]bool {
+	set := make(map[string]bool, len(entries))
+	for _, e := range entries {
+		if e = strings.TrimSpace(e); e != "" {
+			set[e] = true
+		}
+	}
+	return set
+}
+
+func joinSet(set map[string]bool) string {
+	keys := make([]string, 0, len(set))
+	for k := range set {
+		keys = append(keys, k)
+	}
+	slices.Sort(keys)
+	return strings.Join(keys, ", ")
+}
@@ -0,0 +1,335 @@
+package prompts
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"raindrop-mcp/accounts"
+	"raindrop-mcp/api"
+	"raindrop-mcp/collections"
+	"raindrop-mcp/policy"
+	"raindrop-mcp/types"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+)
+
+// Limits on how much live data a prompt embeds
+const (
+	defaultLimit      = 25
+	maxLimit          = 50
+	maxHighlighted    = 10
+	topTagsForContext = 30
+)
+
+// RegisterPrompts registers workflow prompts in the categories allowed by
+// exposes, and returns how many were registered
+func RegisterPrompts(server *mcp.Server, accounts *accounts.Manager, exposes func(category string) bool) int {
+	count := 0
+	addPrompt := func(category string, prompt *mcp.Prompt, build func(ctx context.Context, client *api.Client, args map[string]string) (string, error)) {
+		if !exposes(category) {
+			return
+		}
+		server.AddPrompt(prompt, func(ctx context.Context, req *mcp.GetPromptRequest) (*mcp.GetPromptResult, error) {
+			account, err := accounts.ForRequest(req)
+			if err != nil {
+				return nil, err
+			}
+			text, err := build(ctx, account.Client, req.Params.Arguments)
+			if err != nil {
+				return nil, err
+			}
+			return &mcp.GetPromptResult{
+				Description: prompt.Description,
+				Messages: []*mcp.PromptMessage{{
+					Role:    "user",
+					Content: &mcp.TextContent{Text: text},
+				}},
+			}, nil
+		})
+		count++
+	}
+
+	// Prompt: Triage unsorted bookmarks
+	addPrompt(policy.Bookmarks, &mcp.Prompt{
+		Name:        "triage-unsorted",
+		Title:       "Triage Unsorted Bookmarks",
+		Description: "Suggest a collection and tags for each bookmark in Unsorted",
+		Arguments: []*mcp.PromptArgument{
+			{Name: "limit", Description: fmt.Sprintf("Number of bookmarks to triage (default %d, max %d)", defaultLimit, maxLimit)},
+		},
+	}, func(ctx context.Context, client *api.Client, args map[string]string) (string, error) {
+		limit, err := intArg(args, "limit", defaultLimit)
+		if err != nil {
+			return "", err
+		}
+		raindrops, err := client.SearchRaindrops(ctx, "", -1, 0, clampLimit(limit), nil)
+		if err != nil {
+			return "", fmt.Errorf("failed to get unsorted bookmarks: %w", err)
+		}
+		collections, err := collectionListing(ctx, client)
+		if err != nil {
+			return "", err
+		}
+		tags, err := tagListing(ctx, client, 0, topTagsForContext)
+		if err != nil {
+			return "", err
+		}
+
+		var sb strings.Builder
+		sb.WriteString("Help me triage the bookmarks in my Raindrop.io Unsorted collection.\n\n")
+		sb.WriteString("For each bookmark below, suggest the best existing collection and up to three tags, ")
+		sb.WriteString("preferring tags I already use. Flag anything that looks like a duplicate or is no longer worth keeping. ")
+		sb.WriteString("Present the suggestions as a table and wait for my approval before applying them with update-bookmark.\n\n")
+		sb.WriteString(fmt.Sprintf("## Unsorted bookmarks (%d of %d)\n\n", len(raindrops.Items), raindrops.Count))
+		writeBookmarks(&sb, raindrops.Items, false)
+		sb.WriteString("\n## Collections\n\n" + collections)
+		sb.WriteString("\n## Most used tags\n\n" + tags)
+		return sb.String(), nil
+	})
+
+	// Prompt: Weekly reading digest
+	addPrompt(policy.Bookmarks, &mcp.Prompt{
+		Name:        "weekly-reading-digest",
+		Title:       "Weekly Reading Digest",
+		Description: "Summarise the bookmarks saved recently into a reading digest",
+		Arguments: []*mcp.PromptArgument{
+			{Name: "days", Description: "How many days back to include (default 7)"},
+			{Name: "collection", Description: "Collection ID, title or path to limit the digest to (default all)"},
+		},
+	}, func(ctx context.Context, client *api.Client, args map[string]string) (string, error) {
+		days, err := intArg(args, "days", 7)
+		if err != nil {
+			return "", err
+		}
+		if days < 1 {
+			return "", fmt.Errorf("days must be at least 1")
+		}
+		collectionID, err := collectionArg(ctx, client, args, "collection")
+		if err != nil {
+			return "", err
+		}
+		since := time.Now().AddDate(0, 0, -days).Format("2006-01-02")
+		raindrops, err := client.SearchRaindrops(ctx, "created:>"+since, collectionID, 0, maxLimit, nil)
+		if err != nil {
+			return "", fmt.Errorf("failed to get recent bookmarks: %w", err)
+		}
+
+		var sb strings.Builder
+		sb.WriteString(fmt.Sprintf("Write a reading digest of the bookmarks I saved in the last %d days.\n\n", days))
+		sb.WriteString("Group them by theme, give each group a short heading and summarise every bookmark in one or two sentences ")
+		sb.WriteString("using its excerpt and my notes. Finish with the three items most worth reading first and why.\n\n")
+		sb.WriteString(fmt.Sprintf("## Saved since %s (%d of %d)\n\n", since, len(raindrops.Items), raindrops.Count))
+		if len(raindrops.Items) == 0 {
+			sb.WriteString("No bookmarks were saved in this period.\n")
+		}
+		writeBookmarks(&sb, raindrops.Items, true)
+		return sb.String(), nil
+	})
+
+	// Prompt: Research a topic
+	addPrompt(policy.Bookmarks, &mcp.Prompt{
+		Name:        "research-topic",
+		Title:       "Research a Topic",
+		Description: "Gather saved bookmarks and highlights on a topic into a research brief",
+		Arguments: []*mcp.PromptArgument{
+			{Name: "topic", Description: "Search query for the topic", Required: true},
+			{Name: "limit", Description: fmt.Sprintf("Number of bookmarks to include (default %d, max %d)", defaultLimit, maxLimit)},
+		},
+	}, func(ctx context.Context, client *api.Client, args map[string]string) (string, error) {
+		topic := strings.TrimSpace(args["topic"])
+		if topic == "" {
+			return "", fmt.Errorf("topic is required")
+		}
+		limit, err := intArg(args, "limit", defaultLimit)
+		if err != nil {
+			return "", err
+		}
+		raindrops, err := client.SearchRaindrops(ctx, topic, 0, 0, clampLimit(limit), nil)
+		if err != nil {
+			return "", fmt.Errorf("failed to search bookmarks: %w", err)
+		}
+
+		var sb strings.Builder
+		sb.WriteString(fmt.Sprintf("Help me research %q using what I have already saved in Raindrop.io.\n\n", topic))
+		sb.WriteString("Summarise the main ideas across these sources, quote my highlights where they support a point, ")
+		sb.WriteString("note where sources disagree, and list open questions or gaps worth searching for next.\n\n")
+		sb.WriteString(fmt.Sprintf("## Matching bookmarks (%d of %d)\n\n", len(raindrops.Items), raindrops.Count))
+		if len(raindrops.Items) == 0 {
+			sb.WriteString("Nothing saved matches this topic yet.\n")
+		}
+		writeBookmarks(&sb, raindrops.Items, true)
+
+		// Highlights need one request per bookmark, so only the top results get them
+		var highlighted strings.Builder
+		for i, r := range raindrops.Items {
+			if i == maxHighlighted {
+				break
+			}
+			highlights, err := client.GetHighlights(ctx, r.ID)
+			if err != nil {
+				return "", fmt.Errorf("failed to get highlights for bookmark %d: %w", r.ID, err)
+			}
+			if len(highlights.Items) == 0 {
+				continue
+			}
+			highlighted.WriteString(fmt.Sprintf("### %s (ID: %d)\n", r.Title, r.ID))
+			for _, h := range highlights.Items {
+				highlighted.WriteString(fmt.Sprintf("> %s\n", h.Text))
+				if h.Note != "" {
+					highlighted.WriteString(fmt.Sprintf("Note: %s\n", h.Note))
+				}
+				highlighted.WriteString("\n")
+			}
+		}
+		if highlighted.Len() > 0 {
+			sb.WriteString("\n## Highlights\n\n" + highlighted.String())
+		}
+		return sb.String(), nil
+	})
+
+	// Prompt: Review tags for cleanup
+	addPrompt(policy.Tags, &mcp.Prompt{
+		Name:        "tag-cleanup-review",
+		Title:       "Tag Cleanup Review",
+		Description: "Review all tags for duplicates, typos and rarely used tags",
+		Arguments: []*mcp.PromptArgument{
+			{Name: "collection", Description: "Collection ID, title or path to review tags in (default all)"},
+		},
+	}, func(ctx context.Context, client *api.Client, args map[string]string) (string, error) {
+		collectionID, err := collectionArg(ctx, client, args, "collection")
+		if err != nil {
+			return "", err
+		}
+		tags, err := tagListing(ctx, client, collectionID, 0)
+		if err != nil {
+			return "", err
+		}
+
+		var sb strings.Builder
+		sb.WriteString("Review my Raindrop.io tags and propose a cleanup.\n\n")
+		sb.WriteString("Look for tags that differ only in case, spelling, plural form or separators, tags that are typos, ")
+		sb.WriteString("and tags used only once or twice that could be folded into broader ones. ")
+		sb.WriteString("Group the proposals into merges (merge-tags), renames (rename-tag) and deletions (delete-tags), ")
+		sb.WriteString("and wait for my approval before changing anything.\n\n")
+		sb.WriteString("## Tags and bookmark counts\n\n" + tags)
+		return sb.String(), nil
+	})
+
+	// Prompt: Summarise a collection
+	addPrompt(policy.Bookmarks, &mcp.Prompt{
+		Name:        "summarize-collection",
+		Title:       "Summarize a Collection",
+		Description: "Summarise what a collection contains and how it could be organised",
+		Arguments: []*mcp.PromptArgument{
+			{Name: "collection", Description: "Collection ID, title or path to summarise", Required: true},
+		},
+	}, func(ctx context.Context, client *api.Client, args map[string]string) (string, error) {
+		if strings.TrimSpace(args["collection"]) == "" {
+			return "", fmt.Errorf("collection is required")
+		}
+		collectionID, err := collectionArg(ctx, client, args, "collection")
+		if err != nil {
+			return "", err
+		}
+		collection, err := client.GetCollection(ctx, collectionID)
+		if err != nil {
+			return "", fmt.Errorf("failed to get collection: %w", err)
+		}
+		raindrops, err := client.SearchRaindrops(ctx, "", collectionID, 0, maxLimit, nil)
+		if err != nil {
+			return "", fmt.Errorf("failed to get bookmarks: %w", err)
+		}
+
+		var sb strings.Builder
+		sb.WriteString(fmt.Sprintf("Summarise my Raindrop.io collection %q.\n\n", collection.Title))
+		sb.WriteString("Describe its main themes, point out notable or outdated items, ")
+		sb.WriteString("and suggest sub-collections or tags if it would benefit from more structure.\n\n")
+		sb.WriteString(fmt.Sprintf("## %s (ID: %d, showing %d of %d bookmarks)\n\n", collection.Title, collectionID, len(raindrops.Items), raindrops.Count))
+		writeBookmarks(&sb, raindrops.Items, true)
+		return sb.String(), nil
+	})
+
+	return count
+}
+
+// collectionArg resolves an optional collection argument; it is 0 when not given
+func collectionArg(ctx context.Context, client *api.Client, args map[string]string, name string) (int, error) {
+	return collections.NewResolver(client).Resolve(ctx, collections.Ref(args[name]))
+}
+
+// intArg parses an optional integer prompt argument
+func intArg(args map[string]string, name string, fallback int) (int, error) {
+	value := strings.TrimSpace(args[name])
+	if value == "" {
+		return fallback, nil
+	}
+	n, err := strconv.Atoi(value)
+	if err != nil {
+		return 0, fmt.Errorf("%s must be a number, got %q", name, value)
+	}
+	return n, nil
+}
+
+// clampLimit keeps a requested item count within 1..maxLimit
+func clampLimit(limit int) int {
+	return max(1, min(limit, maxLimit))
+}
+
+// writeBookmarks lists bookmarks with their IDs, optionally with excerpts and notes
+func writeBookmarks(sb *strings.Builder, items []types.Raindrop, details bool) {
+	for _, r := range items {
+		sb.WriteString(fmt.Sprintf("- **%s** (ID: %d)\n", r.Title, r.ID))
+		sb.WriteString(fmt.Sprintf("  URL: %s\n", r.Link))
+		if len(r.Tags) > 0 {
+			sb.WriteString(fmt.Sprintf("  Tags: %s\n", strings.Join(r.Tags, ", ")))
+		}
+		if details {
+			if r.Excerpt != "" {
+				sb.WriteString(fmt.Sprintf("  Excerpt: %s\n", r.Excerpt))
+			}
+			if r.Note != "" {
+				sb.WriteString(fmt.Sprintf("  Note: %s\n", r.Note))
+			}
+		}
+	}
+}
+
+// collectionListing lists every collection with its ID, nested under its parent
+func collectionListing(ctx context.Context, client *api.Client) (string, error) {
+	index, err := collections.Load(ctx, client)
+	if err != nil {
+		return "", err
+	}
+
+	var sb strings.Builder
+	collections.Walk(index.Tree(), func(n *collections.Node) {
+		sb.WriteString(fmt.Sprintf("%s- %s (ID: %d, %d bookmarks)\n", strings.Repeat("  ", n.Depth), n.Collection.Title, n.Collection.FullID, n.Collection.Count))
+	})
+	return sb.String(), nil
+}
+
+// tagListing lists the tags in a collection (0 for all) with their bookmark
+// counts, most used first. A limit of 0 lists every tag.
+func tagListing(ctx context.Context, client *api.Client, collectionID, limit int) (string, error) {
+	tags, err := client.GetTags(ctx, collectionID)
+	if err != nil {
+		return "", fmt.Errorf("failed to get tags: %w", err)
+	}
+	if len(tags.Items) == 0 {
+		return "No tags yet.\n", nil
+	}
+	sort.SliceStable(tags.Items, func(i, j int) bool { return tags.Items[i].Count > tags.Items[j].Count })
+
+	var sb strings.Builder
+	for i, t := range tags.Items {
+		if limit > 0 && i == limit {
+			break
+		}
+		sb.WriteString(fmt.Sprintf("- %s (%d bookmarks)\n", t.ID, t.Count))
+	}
+	return sb.String(), nil
+}
@@ -0,0 +1,321 @@
+package render
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+
+	"raindrop-mcp/types"
+)
+
+// Listing formats
+const (
+	Markdown = "markdown"
+	JSON     = "json"
+	CSV      = "csv"
+	Compact  = "compact"
+)
+
+// Formats lists every listing format
+var Formats = []string{Markdown, JSON, CSV, Compact}
+
+// AllFields selects every field of a listing
+const AllFields = "all"
+
+// Options selects how a listing is rendered
+type Options struct {
+	// Format is one of Formats; empty means Markdown
+	Format string
+	// Fields names the fields to show, in order; empty means the listing's
+	// defaults and AllFields means every field
+	Fields []string
+}
+
+// ValidFormat reports whether format names a listing format
+func ValidFormat(format string) bool {
+	return slices.Contains(Formats, format)
+}
+
+// Bookmarks renders bookmarks; total is the number of matches across all
+// pages, shown in the markdown heading
+func Bookmarks(items []types.Raindrop, total int, opts Options) (string, error) {
+	return bookmarkListing.render(items, total, opts)
+}
+
+// Collections renders collections
+func Collections(items []types.Collection, opts Options) (string, error) {
+	return collectionListing.render(items, len(items), opts)
+}
+
+// Tags renders tags with their bookmark counts
+func Tags(items []types.Tag, opts Options) (string, error) {
+	return tagListing.render(items, len(items), opts)
+}
+
+// Highlights renders highlights
+func Highlights(items []types.Highlight, opts Options) (string, error) {
+	return highlightListing.render(items, len(items), opts)
+}
+
+// BookmarkFields, CollectionFields, TagFields and HighlightFields list the
+// field names each listing accepts
+func BookmarkFields() []string   { return bookmarkListing.names() }
+func CollectionFields() []string { return collectionListing.names() }
+func TagFields() []string        { return tagListing.names() }
+func HighlightFields() []string  { return highlightListing.names() }
+
+// MIMEType returns the media type of a format, for resources
+func MIMEType(format string) string {
+	switch format {
+	case JSON:
+		return "application/json"
+	case CSV:
+		return "text/csv"
+	case Compact:
+		return "text/plain"
+	}
+	return "text/markdown"
+}
+
+// field is a named value of a listed item. Values are strings, numbers,
+// booleans or string slices; nil and empty values are left out of markdown.
+type field[T any] struct {
+	name  string
+	value func(T) any
+}
+
+// listing describes how to render one kind of item
+type listing[T any] struct {
+	// noun is the plural item name used in headings
+	noun   string
+	fields []field[T]
+	// defaults are the fields shown when none are selected; the first is
+	// the markdown heading of each item
+	defaults []string
+}
+
+var bookmarkListing = &listing[types.Raindrop]{
+	noun: "bookmarks",
+	fields: []field[types.Raindrop]{
+		{"id", func(r types.Raindrop) any { return r.ID }},
+		{"title", func(r types.Raindrop) any { return r.Title }},
+		{"link", func(r types.Raindrop) any { return r.Link }},
+		{"domain", func(r types.Raindrop) any { return r.Domain }},
+		{"excerpt", func(r types.Raindrop) any { return r.Excerpt }},
+		{"note", func(r types.Raindrop) any { return r.Note }},
+		{"tags", func(r types.Raindrop) any { return r.Tags }},
+		{"type", func(r types.Raindrop) any { return r.Type }},
+		{"collection", func(r types.Raindrop) any { return r.Collection.ID }},
+		{"important", func(r types.Raindrop) any { return r.Important }},
+		{"cover", func(r types.Raindrop) any { return r.Cover }},
+		{"created", func(r types.Raindrop) any { return r.Created }},
+		{"last_update", func(r types.Raindrop) any { return r.LastUpdate }},
+	},
+	defaults: []string{"title", "id", "link", "tags"},
+}
+
+var collectionListing = &listing[types.Collection]{
+	noun: "collections",
+	fields: []field[types.Collection]{
+		{"id", func(c types.Collection) any { return c.FullID }},
+		{"title", func(c types.Collection) any { return c.Title }},
+		{"count", func(c types.Collection) any { return c.Count }},
+		{"parent", func(c types.Collection) any {
+			if c.Parent == nil {
+				return nil
+			}
+			return c.Parent.ID
+		}},
+		{"public", func(c types.Collection) any { return c.Public }},
+		{"view", func(c types.Collection) any { return c.View }},
+		{"color", func(c types.Collection) any { return c.Color }},
+		{"cover", func(c types.Collection) any { return c.Cover }},
+		{"sort", func(c types.Collection) any { return c.Sort }},
+		{"expanded", func(c types.Collection) any { return c.Expanded }},
+		{"created", func(c types.Collection) any { return c.Created }},
+		{"last_update", func(c types.Collection) any { return c.LastUpdate }},
+	},
+	defaults: []string{"title", "id", "count", "parent"},
+}
+
+var tagListing = &listing[types.Tag]{
+	noun: "tags",
+	fields: []field[types.Tag]{
+		{"name", func(t types.Tag) any { return t.ID }},
+		{"count", func(t types.Tag) any { return t.Count }},
+	},
+	defaults: []string{"name", "count"},
+}
+
+var highlightListing = &listing[types.Highlight]{
+	noun: "highlights",
+	fields: []field[types.Highlight]{
+		{"id", func(h types.Highlight) any { return h.ID }},
+		{"text", func(h types.Highlight) any { return h.Text }},
+		{"note", func(h types.Highlight) any { return h.Note }},
+		{"color", func(h types.Highlight) any { return h.Color }},
+		{"bookmark", func(h types.Highlight) any { return h.RaindropID }},
+		{"created", func(h types.Highlight) any { return h.Created }},
+		{"last_update", func(h types.Highlight) any { return h.LastUpdate }},
+	},
+	defaults: []string{"text", "note", "id"},
+}
+
+func (l *listing[T]) names() []string {
+	names := make([]string, len(l.fields))
+	for i, f := range l.fields {
+		names[i] = f.name
+	}
+	return names
+}
+
+// selected resolves the requested field names
+func (l *listing[T]) selected(names []string) ([]field[T], error) {
+	switch {
+	case len(names) == 0:
+		names = l.defaults
+	case len(names) == 1 && names[0] == AllFields:
+		return l.fields, nil
+	}
+
+	selected := make([]field[T], 0, len(names))
+	for _, name := range names {
+		i := slices.IndexFunc(l.fields, func(f field[T]) bool { return f.name == strings.TrimSpace(name) })
+		if i < 0 {
+			return nil, fmt.Errorf("unknown %s field %q; valid fields are %s or %q", l.noun, name, strings.Join(l.names(), ", "), AllFields)
+		}
+		selected = append(selected, l.fields[i])
+	}
+	return selected, nil
+}
+
+func (l *listing[T]) render(items []T, total int, opts Options) (string, error) {
+	fields, err := l.selected(opts.Fields)
+	if err != nil {
+		return "", err
+	}
+
+	switch opts.Format {
+	case "", Markdown:
+		return l.markdown(items, total, fields), nil
+	case JSON:
+		return l.json(items, fields)
+	case CSV:
+		return l.csv(items, fields)
+	case Compact:
+		return l.compact(items, fields), nil
+	}
+	return "", unknownFormat(opts.Format, Formats)
+}
+
+// unknownFormat is the error for a format outside valid
+func unknownFormat(format string, valid []string) error {
+	return fmt.Errorf("unknown format %q; valid formats are %s", format, strings.Join(valid, ", "))
+}
+
+// CountNoun formats n with noun, adding an s unless n is 1
+func CountNoun(n int, noun string) string {
+	if n == 1 {
+		return fmt.Sprintf("1 %s", noun)
+	}
+	return fmt.Sprintf("%d %ss", n, noun)
+}
+
+// markdown shows each item as a numbered heading from the first field and
+// one line with the others
+func (l *listing[T]) markdown(items []T, total int, fields []field[T]) string {
+	if len(items) == 0 {
+		return fmt.Sprintf("No %s found.", l.noun)
+	}
+
+	var sb strings.Builder
+	sb.WriteString(fmt.Sprintf("Found %d %s:\n\n", total, l.noun))
+	for i, item := range items {
+		sb.WriteString(fmt.Sprintf("%d. **%s**\n", i+1, text(fields[0].value(item), ", ")))
+		var details []string
+		for _, f := range fields[1:] {
+			if v := text(f.value(item), ", "); v != "" {
+				details = append(details, fmt.Sprintf("%s: %s", f.name, v))
+			}
+		}
+		if len(details) > 0 {
+			sb.WriteString("   " + strings.Join(details, " | ") + "\n")
+		}
+	}
+	return sb.String()
+}
+
+// json encodes the items as an array of objects keyed by field name
+func (l *listing[T]) json(items []T, fields []field[T]) (string, error) {
+	rows := make([]map[string]any, len(items))
+	for i, item := range items {
+		row := make(map[string]any, len(fields))
+		for _, f := range fields {
+			row[f.name] = f.value(item)
+		}
+		rows[i] = row
+	}
+	data, err := json.MarshalIndent(rows, "", "  ")
+	if err != nil {
+		return "", fmt.Errorf("failed to encode %s: %w", l.noun, err)
+	}
+	return string(data), nil
+}
+
+// csv writes a header row of field names and one row per item
+func (l *listing[T]) csv(items []T, fields []field[T]) (string, error) {
+	var buf bytes.Buffer
+	w := csv.NewWriter(&buf)
+	header := make([]string, len(fields))
+	for i, f := range fields {
+		header[i] = f.name
+	}
+	w.Write(header)
+	for _, item := range items {
+		row := make([]string, len(fields))
+		for i, f := range fields {
+			row[i] = text(f.value(item), ";")
+		}
+		w.Write(row)
+	}
+	w.Flush()
+	if err := w.Error(); err != nil {
+		return "", fmt.Errorf("failed to encode %s: %w", l.noun, err)
+	}
+	return buf.String(), nil
+}
+
+// compact puts each item on one line
+func (l *listing[T]) compact(items []T, fields []field[T]) string {
+	var sb strings.Builder
+	for _, item := range items {
+		values := make([]string, len(fields))
+		for i, f := range fields {
+			values[i] = strings.ReplaceAll(text(f.value(item), ","), "\n", " ")
+		}
+		sb.WriteString(strings.Join(values, " | ") + "\n")
+	}
+	return sb.String()
+}
+
+// text formats a field value, joining lists with sep. Zero numbers and
+// false are kept; nil and empty values become "".
+func text(v any, sep string) string {
+	switch v := v.(type) {
+	case nil:
+		return ""
+	case string:
+		return v
+	case int:
+		return strconv.Itoa(v)
+	case bool:
+		return strconv.FormatBool(v)
+	case []string:
+		return strings.Join(v, sep)
+	}
+	return fmt.Sprint(v)
+}
@@ -0,0 +1,152 @@
+package render
+
+import (
+	"strings"
+	"testing"
+
+	"raindrop-mcp/types"
+)
+
+var testBookmarks = []types.Raindrop{
+	{ID: 1, Title: "Go blog", Link: "https://go.dev/blog", Tags: []string{"go", "golang"}, Collection: types.Collection{ID: 7}},
+	{ID: 2, Title: "Notes, drafts", Link: "https://example.com/a", Note: "line one\nline two", Important: true},
+}
+
+func TestBookmarksFormats(t *testing.T) {
+	tests := []struct {
+		name string
+		opts Options
+		want string
+	}{
+		{
+			name: "markdown defaults",
+			opts: Options{},
+			want: "Found 5 bookmarks:\n\n" +
+				"1. **Go blog**\n   id: 1 | link: https://go.dev/blog | tags: go, golang\n" +
+				"2. **Notes, drafts**\n   id: 2 | link: https://example.com/a\n",
+		},
+		{
+			name: "markdown fields",
+			opts: Options{Format: Markdown, Fields: []string{"id", "important"}},
+			want: "Found 5 bookmarks:\n\n1. **1**\n   important: false\n2. **2**\n   important: true\n",
+		},
+		{
+			name: "json",
+			opts: Options{Format: JSON, Fields: []string{"id", "tags", "collection"}},
+			want: `[
+  {
+    "collection": 7,
+    "id": 1,
+    "tags": [
+      "go",
+      "golang"
+    ]
+  },
+  {
+    "collection": 0,
+    "id": 2,
+    "tags": null
+  }
+]`,
+		},
+		{
+			name: "csv",
+			opts: Options{Format: CSV, Fields: []string{"id", "title", "tags", "note"}},
+			want: "id,title,tags,note\n1,Go blog,go;golang,\n2,\"Notes, drafts\",,\"line one\nline two\"\n",
+		},
+		{
+			name: "compact",
+			opts: Options{Format: Compact, Fields: []string{"id", "title", "tags", "note"}},
+			want: "1 | Go blog | go,golang | \n2 | Notes, drafts |  | line one line two\n",
+		},
+		{
+			name: "fields trimmed",
+			opts: Options{Format: Compact, Fields: []string{" id ", "title"}},
+			want: "1 | Go blog\n2 | Notes, drafts\n",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Bookmarks(testBookmarks, 5, tt.opts)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != tt.want {
+				t.Errorf("Bookmarks() =\n%s\nwant\n%s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestAllFields(t *testing.T) {
+	got, err := Bookmarks(testBookmarks[:1], 1, Options{Format: CSV, Fields: []string{AllFields}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	header, _, _ := strings.Cut(got, "\n")
+	if want := strings.Join(BookmarkFields(), ","); header != want {
+		t.Errorf("header = %q, want %q", header, want)
+	}
+}
+
+func TestEmptyListings(t *testing.T) {
+	tests := []struct {
+		format string
+		want   string
+	}{
+		{Markdown, "No bookmarks found."},
+		{JSON, "[]"},
+		{CSV, "title,id,link,tags\n"},
+		{Compact, ""},
+	}
+	for _, tt := range tests {
+		got, err := Bookmarks(nil, 0, Options{Format: tt.format})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got != tt.want {
+			t.Errorf("%s: Bookmarks(nil) = %q, want %q", tt.format, got, tt.want)
+		}
+	}
+}
+
+func TestRenderErrors(t *testing.T) {
+	tests := []struct {
+		name    string
+		opts    Options
+		wantErr string
+	}{
+		{"unknown format", Options{Format: "xml"}, `unknown format "xml"`},
+		{"unknown field", Options{Fields: []string{"title", "colour"}}, `unknown bookmarks field "colour"`},
+		{"all with others", Options{Fields: []string{AllFields, "id"}}, `unknown bookmarks field "all"`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := Bookmarks(testBookmarks, 2, tt.opts)
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("error = %v, want one containing %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestCollectionsParent(t *testing.T) {
+	items := []types.Collection{
+		{FullID: 5, Title: "Work", Count: 2},
+		{FullID: 7, Title: "Go", Count: 1, Parent: &types.Parent{ID: 5}},
+	}
+	got, err := Collections(items, Options{Format: Compact})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := "Work | 5 | 2 | \nGo | 7 | 1 | 5\n"; got != want {
+		t.Errorf("Collections() = %q, want %q", got, want)
+	}
+}
+
+func TestTreeUnknownFormat(t *testing.T) {
+	_, err := Tree(nil, 0, Options{Format: CSV})
+	if want := `unknown format "csv"; valid formats are markdown, json`; err == nil || err.Error() != want {
+		t.Errorf("error = %v, want %q", err, want)
+	}
+}
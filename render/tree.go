@@ -0,0 +1,117 @@
+package render
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"raindrop-mcp/collections"
+)
+
+// TreeFormats lists the formats a collection tree can be rendered in
+var TreeFormats = []string{Markdown, JSON}
+
+// TreeFormat returns the tree format to use for a listing format: trees
+// are JSON when listings are, and markdown otherwise
+func TreeFormat(listingFormat string) string {
+	if listingFormat == JSON {
+		return JSON
+	}
+	return Markdown
+}
+
+// treeNode is the JSON form of a collection tree node
+type treeNode struct {
+	ID           int         `json:"id"`
+	Title        string      `json:"title"`
+	Path         string      `json:"path"`
+	Count        int         `json:"count"`
+	SubtreeCount int         `json:"subtree_count"`
+	Hidden       int         `json:"hidden_subcollections,omitempty"`
+	Children     []*treeNode `json:"children,omitempty"`
+}
+
+// Tree renders a collection tree, or a subtree, down to depth levels below
+// its top (0 for all) as a nested markdown list or nested JSON objects;
+// opts.Format is one of TreeFormats and opts.Fields is not used.
+// Nodes whose children are cut off by the depth say how many subcollections
+// are hidden.
+func Tree(tree []*collections.Node, depth int, opts Options) (string, error) {
+	// Depths below are counted from the root collections
+	top := 0
+	if len(tree) > 0 {
+		top = tree[0].Depth
+	}
+	if depth > 0 {
+		depth += top
+	}
+
+	switch opts.Format {
+	case "", Markdown:
+		if len(tree) == 0 {
+			return "No collections found.", nil
+		}
+		var sb strings.Builder
+		writeTree(&sb, tree, top, depth)
+		return sb.String(), nil
+	case JSON:
+		data, err := json.MarshalIndent(jsonTree(tree, depth), "", "  ")
+		if err != nil {
+			return "", fmt.Errorf("failed to encode collection tree: %w", err)
+		}
+		return string(data), nil
+	}
+	return "", unknownFormat(opts.Format, TreeFormats)
+}
+
+func writeTree(sb *strings.Builder, nodes []*collections.Node, top, depth int) {
+	for _, n := range nodes {
+		c := n.Collection
+		sb.WriteString(fmt.Sprintf("%s- **%s** (ID: %d): %s", strings.Repeat("  ", n.Depth-top), c.Title, c.FullID, CountNoun(c.Count, "bookmark")))
+		if len(n.Children) > 0 {
+			sb.WriteString(fmt.Sprintf(", %d including subcollections", n.SubtreeCount))
+		}
+		if hidden := hiddenBelow(n, depth); hidden > 0 {
+			sb.WriteString(fmt.Sprintf(" (%s not shown)", CountNoun(hidden, "subcollection")))
+		}
+		sb.WriteString("\n")
+		if !cutOff(n, depth) {
+			writeTree(sb, n.Children, top, depth)
+		}
+	}
+}
+
+func jsonTree(nodes []*collections.Node, depth int) []*treeNode {
+	out := make([]*treeNode, 0, len(nodes))
+	for _, n := range nodes {
+		node := &treeNode{
+			ID:           n.Collection.FullID,
+			Title:        n.Collection.Title,
+			Path:         n.Path,
+			Count:        n.Collection.Count,
+			SubtreeCount: n.SubtreeCount,
+			Hidden:       hiddenBelow(n, depth),
+		}
+		if !cutOff(n, depth) && len(n.Children) > 0 {
+			node.Children = jsonTree(n.Children, depth)
+		}
+		out = append(out, node)
+	}
+	return out
+}
+
+// cutOff reports whether the children of n are beyond depth, counted from
+// the root collections
+func cutOff(n *collections.Node, depth int) bool {
+	return depth > 0 && n.Depth+1 >= depth
+}
+
+// hiddenBelow counts the subcollections of n that depth leaves out
+func hiddenBelow(n *collections.Node, depth int) int {
+	if !cutOff(n, depth) {
+		return 0
+	}
+	hidden := -1 // n itself
+	collections.Walk([]*collections.Node{n}, func(*collections.Node) { hidden++ })
+	return hidden
+}
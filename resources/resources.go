@@ -5,84 +5,127 @@ import (
 	"fmt"
 	"strings"
 
+	"raindrop-mcp/accounts"
 	"raindrop-mcp/api"
+	"raindrop-mcp/collections"
+	"raindrop-mcp/policy"
+	"raindrop-mcp/render"
 
 	"github.com/modelcontextprotocol/go-sdk/mcp"
 )
 
-// RegisterResources registers MCP resources for Raindrop data
-func RegisterResources(server *mcp.Server, client *api.Client) {
+// Options configures resources
+type Options struct {
+	// Format is the listing format (see render.Formats)
+	Format string
+	// Exposes reports whether resources of a category may be offered
+	Exposes func(category string) bool
+}
+
+// RegisterResources registers MCP resources for Raindrop data in the categories
+// allowed by opts.Exposes, and returns how many were registered
+func RegisterResources(server *mcp.Server, accounts *accounts.Manager, opts Options) int {
+	exposes := opts.Exposes
+	count := 0
+	addResource := func(category string, resource *mcp.Resource, h mcp.ResourceHandler) {
+		if exposes(category) {
+			server.AddResource(resource, h)
+			count++
+		}
+	}
+	addTemplate := func(category string, template *mcp.ResourceTemplate, h mcp.ResourceHandler) {
+		if exposes(category) {
+			server.AddResourceTemplate(template, h)
+			count++
+		}
+	}
+
 	// Resource: All collections
-	server.AddResource(&mcp.Resource{
-		URI:         "raindrop://collections",
+	addResource(policy.Collections, &mcp.Resource{
+		URI:         collectionsURI,
 		Name:        "All Collections",
 		Description: "List of all Raindrop.io collections",
-		MIMEType:    "text/plain",
+		MIMEType:    render.MIMEType(opts.Format),
 	}, func(ctx context.Context, req *mcp.ReadResourceRequest) (*mcp.ReadResourceResult, error) {
-		rootCollections, err := client.ListCollections()
+		client, err := sessionClient(accounts, req)
 		if err != nil {
-			return nil, fmt.Errorf("failed to list collections: %w", err)
+			return nil, err
 		}
-		childCollections, err := client.ListChildCollections()
+
+		index, err := collections.Load(ctx, client)
 		if err != nil {
-			return nil, fmt.Errorf("failed to list child collections: %w", err)
+			return nil, err
 		}
 
-		var sb strings.Builder
-		sb.WriteString("# Raindrop.io Collections\n\n")
+		text, err := render.Collections(index.Collections, render.Options{Format: opts.Format})
+		if err != nil {
+			return nil, err
+		}
+		return textResource(req, opts.Format, text), nil
+	})
 
-		allCollections := append(rootCollections.Items, childCollections.Items...)
-		for _, c := range allCollections {
-			indent := ""
-			if c.Parent != nil && c.Parent.ID > 0 {
-				indent = "  "
-			}
-			sb.WriteString(fmt.Sprintf("%s- %s (ID: %d, %d bookmarks)\n", indent, c.Title, c.FullID, c.Count))
+	// Resource: Collection tree
+	treeFormat := render.TreeFormat(opts.Format)
+	addResource(policy.Collections, &mcp.Resource{
+		URI:         collectionTreeURI,
+		Name:        "Collection Tree",
+		Description: "All Raindrop.io collections nested under their parents, with bookmark counts",
+		MIMEType:    render.MIMEType(treeFormat),
+	}, func(ctx context.Context, req *mcp.ReadResourceRequest) (*mcp.ReadResourceResult, error) {
+		client, err := sessionClient(accounts, req)
+		if err != nil {
+			return nil, err
 		}
 
-		return &mcp.ReadResourceResult{
-			Contents: []*mcp.ResourceContents{{
-				URI:  req.Params.URI,
-				Text: sb.String(),
-			}},
-		}, nil
+		index, err := collections.Load(ctx, client)
+		if err != nil {
+			return nil, err
+		}
+
+		text, err := render.Tree(index.Tree(), 0, render.Options{Format: treeFormat})
+		if err != nil {
+			return nil, err
+		}
+		return textResource(req, treeFormat, text), nil
 	})
 
 	// Resource: All tags
-	server.AddResource(&mcp.Resource{
-		URI:         "raindrop://tags",
+	addResource(policy.Tags, &mcp.Resource{
+		URI:         tagsURI,
 		Name:        "All Tags",
 		Description: "List of all Raindrop.io tags",
-		MIMEType:    "text/plain",
+		MIMEType:    render.MIMEType(opts.Format),
 	}, func(ctx context.Context, req *mcp.ReadResourceRequest) (*mcp.ReadResourceResult, error) {
-		tags, err := client.GetTags(0)
+		client, err := sessionClient(accounts, req)
 		if err != nil {
-			return nil, fmt.Errorf("failed to get tags: %w", err)
+			return nil, err
 		}
 
-		var sb strings.Builder
-		sb.WriteString("# Raindrop.io Tags\n\n")
-
-		for _, t := range tags.Items {
-			sb.WriteString(fmt.Sprintf("- %s (%d bookmarks)\n", t.ID, t.Count))
+		tags, err := client.GetTags(ctx, 0)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get tags: %w", err)
 		}
 
-		return &mcp.ReadResourceResult{
-			Contents: []*mcp.ResourceContents{{
-				URI:  req.Params.URI,
-				Text: sb.String(),
-			}},
-		}, nil
+		text, err := render.Tags(tags.Items, render.Options{Format: opts.Format})
+		if err != nil {
+			return nil, err
+		}
+		return textResource(req, opts.Format, text), nil
 	})
 
 	// Resource: User info
-	server.AddResource(&mcp.Resource{
-		URI:         "raindrop://user",
+	addResource(policy.Account, &mcp.Resource{
+		URI:         userURI,
 		Name:        "User Info",
 		Description: "Current Raindrop.io user information",
 		MIMEType:    "text/plain",
 	}, func(ctx context.Context, req *mcp.ReadResourceRequest) (*mcp.ReadResourceResult, error) {
-		user, err := client.GetUser()
+		client, err := sessionClient(accounts, req)
+		if err != nil {
+			return nil, err
+		}
+
+		user, err := client.GetUser(ctx)
 		if err != nil {
 			return nil, fmt.Errorf("failed to get user: %w", err)
 		}
@@ -106,43 +149,59 @@ func RegisterResources(server *mcp.Server, client *api.Client) {
 	})
 
 	// Resource Template: Bookmarks in collection
-	server.AddResourceTemplate(&mcp.ResourceTemplate{
+	addTemplate(policy.Bookmarks, &mcp.ResourceTemplate{
 		URITemplate: "raindrop://collection/{id}/bookmarks",
 		Name:        "Collection Bookmarks",
 		Description: "Bookmarks in a specific collection",
-		MIMEType:    "text/plain",
-	}, func(ctx context.Context, req *mcp.ReadResourceRequest) (*mcp.ReadResourceResult, error) {
-		// Parse collection ID from URI
-		uri := req.Params.URI
-		var collectionID int
-		_, err := fmt.Sscanf(uri, "raindrop://collection/%d/bookmarks", &collectionID)
+		MIMEType:    render.MIMEType(opts.Format),
+	}, collectionBookmarks(accounts, opts.Format))
+
+	return count
+}
+
+// collectionBookmarks reads raindrop://collection/{id}/bookmarks, both for the
+// template and for the per-collection resources listed by the Watcher
+func collectionBookmarks(accounts *accounts.Manager, format string) mcp.ResourceHandler {
+	return func(ctx context.Context, req *mcp.ReadResourceRequest) (*mcp.ReadResourceResult, error) {
+		client, err := sessionClient(accounts, req)
 		if err != nil {
-			return nil, fmt.Errorf("invalid collection URI: %w", err)
+			return nil, err
 		}
 
-		raindrops, err := client.SearchRaindrops("", collectionID, 0, 25, nil)
+		collectionID, ok := parseBookmarksURI(req.Params.URI)
+		if !ok {
+			return nil, fmt.Errorf("invalid collection URI %q", req.Params.URI)
+		}
+
+		raindrops, err := client.SearchRaindrops(ctx, "", collectionID, 0, 25, nil)
 		if err != nil {
 			return nil, fmt.Errorf("failed to get bookmarks: %w", err)
 		}
 
-		var sb strings.Builder
-		sb.WriteString(fmt.Sprintf("# Bookmarks in Collection %d\n\n", collectionID))
-		sb.WriteString(fmt.Sprintf("Total: %d bookmarks\n\n", raindrops.Count))
-
-		for _, r := range raindrops.Items {
-			sb.WriteString(fmt.Sprintf("- **%s**\n", r.Title))
-			sb.WriteString(fmt.Sprintf("  URL: %s\n", r.Link))
-			if len(r.Tags) > 0 {
-				sb.WriteString(fmt.Sprintf("  Tags: %s\n", strings.Join(r.Tags, ", ")))
-			}
-			sb.WriteString("\n")
+		text, err := render.Bookmarks(raindrops.Items, raindrops.Count, render.Options{Format: format})
+		if err != nil {
+			return nil, err
 		}
+		return textResource(req, format, text), nil
+	}
+}
 
-		return &mcp.ReadResourceResult{
-			Contents: []*mcp.ResourceContents{{
-				URI:  req.Params.URI,
-				Text: sb.String(),
-			}},
-		}, nil
-	})
+// textResource returns text as the contents of the requested resource
+func textResource(req *mcp.ReadResourceRequest, format, text string) *mcp.ReadResourceResult {
+	return &mcp.ReadResourceResult{
+		Contents: []*mcp.ResourceContents{{
+			URI:      req.Params.URI,
+			MIMEType: render.MIMEType(format),
+			Text:     text,
+		}},
+	}
+}
+
+// sessionClient returns the API client for the session's active account
+func sessionClient(accounts *accounts.Manager, req *mcp.ReadResourceRequest) (*api.Client, error) {
+	account, err := accounts.ForRequest(req)
+	if err != nil {
+		return nil, err
+	}
+	return account.Client, nil
 }
@@ -0,0 +1,390 @@
+package resources
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"raindrop-mcp/accounts"
+	"raindrop-mcp/api"
+	"raindrop-mcp/policy"
+	"raindrop-mcp/render"
+	"raindrop-mcp/types"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+)
+
+// Resource URIs the Watcher can poll
+const (
+	collectionsURI    = "raindrop://collections"
+	collectionTreeURI = "raindrop://collections/tree"
+	tagsURI           = "raindrop://tags"
+	userURI           = "raindrop://user"
+	bookmarksURI      = "raindrop://collection/%d/bookmarks"
+)
+
+// Watcher polls Raindrop for changes to subscribed resources and sends
+// notifications/resources/updated when they change. It also lists a
+// bookmarks resource for every collection of the default profile, adding and
+// removing them as collections come and go.
+//
+// The SDK sends updates to every session subscribed to a URI, which would
+// tell users of a multi-user server when other accounts change, so the
+// watcher is for single-user servers only.
+//
+// Changes are detected from collection counts and lastUpdate times and tag
+// counts, so each poll costs a few requests per account however many
+// resources are subscribed. While nothing is subscribed, polls after the
+// first make no requests.
+type Watcher struct {
+	server   *mcp.Server
+	accounts *accounts.Manager
+	opts     Options
+	interval time.Duration
+
+	mu          sync.Mutex
+	subscribers map[*mcp.ServerSession]*subscriber
+	listed      map[int]string // collection ID -> title of listed bookmarks resources
+	synced      bool           // the collections have been listed once
+}
+
+// subscriber is a session's subscriptions and the state it last saw.
+// Its account is looked up on each poll because sessions can switch profiles.
+type subscriber struct {
+	seen map[string]string // URI -> fingerprint
+}
+
+// NewWatcher creates a watcher for the resources allowed by opts.Exposes
+func NewWatcher(server *mcp.Server, accounts *accounts.Manager, opts Options, interval time.Duration) *Watcher {
+	return &Watcher{
+		server:      server,
+		accounts:    accounts,
+		opts:        opts,
+		interval:    interval,
+		subscribers: make(map[*mcp.ServerSession]*subscriber),
+		listed:      make(map[int]string),
+	}
+}
+
+// Subscribe handles resources/subscribe
+func (w *Watcher) Subscribe(ctx context.Context, req *mcp.SubscribeRequest) error {
+	uri := req.Params.URI
+	if !w.watchable(uri) {
+		return fmt.Errorf("resource %q does not support subscriptions", uri)
+	}
+	account, err := w.accounts.ForRequest(req)
+	if err != nil {
+		return err
+	}
+
+	// Record the current state so only later changes are reported
+	fingerprint, err := newPoll(ctx, account.Client).fingerprint(uri)
+	if err != nil {
+		return err
+	}
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	sub, ok := w.subscribers[req.Session]
+	if !ok {
+		sub = &subscriber{seen: make(map[string]string)}
+		w.subscribers[req.Session] = sub
+	}
+	sub.seen[uri] = fingerprint
+	slog.DebugContext(ctx, "Resource subscribed", "uri", uri)
+	return nil
+}
+
+// Unsubscribe handles resources/unsubscribe
+func (w *Watcher) Unsubscribe(ctx context.Context, req *mcp.UnsubscribeRequest) error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if sub, ok := w.subscribers[req.Session]; ok {
+		delete(sub.seen, req.Params.URI)
+		if len(sub.seen) == 0 {
+			delete(w.subscribers, req.Session)
+		}
+	}
+	return nil
+}
+
+// Forget drops the subscriptions of a closed session
+func (w *Watcher) Forget(ss *mcp.ServerSession) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	delete(w.subscribers, ss)
+}
+
+// Run polls until ctx is done
+func (w *Watcher) Run(ctx context.Context) {
+	ticker := time.NewTicker(w.interval)
+	defer ticker.Stop()
+	for {
+		w.poll(ctx)
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// watchable reports whether uri names a resource that can be subscribed to
+func (w *Watcher) watchable(uri string) bool {
+	switch uri {
+	case collectionsURI, collectionTreeURI:
+		return w.opts.Exposes(policy.Collections)
+	case tagsURI:
+		return w.opts.Exposes(policy.Tags)
+	case userURI:
+		return w.opts.Exposes(policy.Account)
+	}
+	_, ok := parseBookmarksURI(uri)
+	return ok && w.opts.Exposes(policy.Bookmarks)
+}
+
+// poll checks every subscription once and refreshes the collection listing
+func (w *Watcher) poll(ctx context.Context) {
+	// Each account is queried once per poll, however many sessions use it
+	polls := make(map[*accounts.Account]*poll)
+	pollFor := func(account *accounts.Account) *poll {
+		p, ok := polls[account]
+		if !ok {
+			p = newPoll(ctx, account.Client)
+			polls[account] = p
+		}
+		return p
+	}
+
+	w.mu.Lock()
+	uris := make(map[*mcp.ServerSession][]string, len(w.subscribers))
+	for ss, sub := range w.subscribers {
+		for uri := range sub.seen {
+			uris[ss] = append(uris[ss], uri)
+		}
+	}
+	idle := len(uris) == 0 && w.synced
+	w.mu.Unlock()
+	if idle {
+		return
+	}
+
+	updated := make(map[string]bool)
+	for ss := range uris {
+		account, err := w.accounts.ForSession(ss)
+		if err != nil {
+			slog.Warn("Failed to open account for subscriptions", "session", ss.ID(), "error", err)
+			continue
+		}
+		p := pollFor(account)
+		for _, uri := range uris[ss] {
+			fingerprint, err := p.fingerprint(uri)
+			if err != nil {
+				slog.Warn("Failed to poll resource", "uri", uri, "error", err)
+				continue
+			}
+			if w.see(ss, uri, fingerprint) {
+				updated[uri] = true
+			}
+		}
+	}
+
+	// The SDK notifies every session subscribed to a URI. Sessions on other
+	// profiles may get an extra notification; re-reading shows them their own data.
+	for uri := range updated {
+		slog.Debug("Resource changed", "uri", uri)
+		if err := w.server.ResourceUpdated(ctx, &mcp.ResourceUpdatedNotificationParams{URI: uri}); err != nil {
+			slog.Warn("Failed to send resource update", "uri", uri, "error", err)
+		}
+	}
+
+	if !w.opts.Exposes(policy.Bookmarks) {
+		return
+	}
+	account, err := w.accounts.Account(w.accounts.DefaultProfile())
+	if err != nil {
+		slog.Warn("Failed to open account for collection resources", "error", err)
+		return
+	}
+	collections, err := pollFor(account).collections()
+	if err != nil {
+		slog.Warn("Failed to list collections for resources", "error", err)
+		return
+	}
+	w.syncCollections(collections)
+}
+
+// see records the fingerprint a session saw and reports whether it changed.
+// Subscriptions removed during the poll are left alone.
+func (w *Watcher) see(ss *mcp.ServerSession, uri, fingerprint string) bool {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	sub, ok := w.subscribers[ss]
+	if !ok {
+		return false
+	}
+	previous, ok := sub.seen[uri]
+	if !ok || previous == fingerprint {
+		return false
+	}
+	sub.seen[uri] = fingerprint
+	return true
+}
+
+// syncCollections lists a bookmarks resource for each collection and removes
+// those of deleted collections. The SDK sends list_changed for each change.
+func (w *Watcher) syncCollections(collections []types.Collection) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	w.synced = true
+	current := make(map[int]bool, len(collections))
+	for _, c := range collections {
+		current[c.FullID] = true
+		if title, ok := w.listed[c.FullID]; ok && title == c.Title {
+			continue
+		}
+		w.server.AddResource(&mcp.Resource{
+			URI:         fmt.Sprintf(bookmarksURI, c.FullID),
+			Name:        c.Title,
+			Description: fmt.Sprintf("Bookmarks in collection %q", c.Title),
+			MIMEType:    render.MIMEType(w.opts.Format),
+		}, collectionBookmarks(w.accounts, w.opts.Format))
+		w.listed[c.FullID] = c.Title
+	}
+
+	var removed []string
+	for id := range w.listed {
+		if !current[id] {
+			removed = append(removed, fmt.Sprintf(bookmarksURI, id))
+			delete(w.listed, id)
+		}
+	}
+	if len(removed) > 0 {
+		w.server.RemoveResources(removed...)
+	}
+}
+
+// poll fetches an account's data at most once while fingerprinting resources
+type poll struct {
+	ctx    context.Context
+	client *api.Client
+
+	collectionList []types.Collection
+	collectionErr  error
+	fetched        bool
+}
+
+// newPoll reads around client's response cache, so changes show up on the
+// next poll rather than when cached responses expire
+func newPoll(ctx context.Context, client *api.Client) *poll {
+	return &poll{ctx: ctx, client: client.Uncached()}
+}
+
+// collections returns the root and child collections
+func (p *poll) collections() ([]types.Collection, error) {
+	if !p.fetched {
+		p.fetched = true
+		rootCollections, err := p.client.ListCollections(p.ctx)
+		if err != nil {
+			p.collectionErr = fmt.Errorf("failed to list collections: %w", err)
+			return nil, p.collectionErr
+		}
+		childCollections, err := p.client.ListChildCollections(p.ctx)
+		if err != nil {
+			p.collectionErr = fmt.Errorf("failed to list child collections: %w", err)
+			return nil, p.collectionErr
+		}
+		p.collectionList = append(rootCollections.Items, childCollections.Items...)
+	}
+	return p.collectionList, p.collectionErr
+}
+
+// fingerprint summarises the state of a resource; it changes when the
+// resource's content does
+func (p *poll) fingerprint(uri string) (string, error) {
+	var parts []string
+	switch uri {
+	case collectionsURI, collectionTreeURI:
+		collections, err := p.collections()
+		if err != nil {
+			return "", err
+		}
+		for _, c := range collections {
+			parent := 0
+			if c.Parent != nil {
+				parent = c.Parent.ID
+			}
+			parts = append(parts, fmt.Sprintf("%d|%s|%d|%s|%d", c.FullID, c.Title, c.Count, c.LastUpdate, parent))
+		}
+
+	case tagsURI:
+		tags, err := p.client.GetTags(p.ctx, 0)
+		if err != nil {
+			return "", fmt.Errorf("failed to get tags: %w", err)
+		}
+		for _, t := range tags.Items {
+			parts = append(parts, fmt.Sprintf("%s|%d", t.ID, t.Count))
+		}
+
+	case userURI:
+		user, err := p.client.GetUser(p.ctx)
+		if err != nil {
+			return "", fmt.Errorf("failed to get user: %w", err)
+		}
+		data, err := json.Marshal(user)
+		if err != nil {
+			return "", err
+		}
+		parts = append(parts, string(data))
+
+	default:
+		id, ok := parseBookmarksURI(uri)
+		if !ok {
+			return "", fmt.Errorf("resource %q does not support subscriptions", uri)
+		}
+		if id <= 0 {
+			// System collections are not listed; use their newest bookmark instead
+			raindrops, err := p.client.SearchRaindrops(p.ctx, "", id, 0, 1, nil)
+			if err != nil {
+				return "", fmt.Errorf("failed to get bookmarks: %w", err)
+			}
+			parts = append(parts, fmt.Sprintf("%d", raindrops.Count))
+			for _, r := range raindrops.Items {
+				parts = append(parts, fmt.Sprintf("%d|%s", r.ID, r.LastUpdate))
+			}
+			break
+		}
+		collections, err := p.collections()
+		if err != nil {
+			return "", err
+		}
+		parts = append(parts, "missing")
+		for _, c := range collections {
+			if c.FullID == id {
+				parts = []string{fmt.Sprintf("%d|%s", c.Count, c.LastUpdate)}
+				break
+			}
+		}
+	}
+
+	slices.Sort(parts)
+	sum := sha256.Sum256([]byte(strings.Join(parts, "\n")))
+	return hex.EncodeToString(sum[:]), nil
+}
+
+// parseBookmarksURI extracts the collection ID from a collection bookmarks URI
+func parseBookmarksURI(uri string) (int, bool) {
+	var id int
+	if _, err := fmt.Sscanf(uri, bookmarksURI, &id); err != nil || fmt.Sprintf(bookmarksURI, id) != uri {
+		return 0, false
+	}
+	return id, true
+}
@@ -0,0 +1,183 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"time"
+
+	"raindrop-mcp/api"
+	"raindrop-mcp/auth"
+	"raindrop-mcp/config"
+	"raindrop-mcp/logging"
+)
+
+// tokenSource describes where a profile's access token comes from
+type tokenSource struct {
+	Profile *auth.Profile
+	// Description names the origin of the token, for status output
+	Description string
+	// Token is nil when no token has been saved yet
+	Token *auth.TokenData
+	// Store is nil for tokens taken from profile settings or the environment
+	Store auth.TokenStore
+	// OAuth is nil when no OAuth app is configured
+	OAuth *auth.OAuthConfig
+}
+
+// findToken locates the access token for a profile without starting an OAuth flow
+// Priority:
+// 1. Profile test token, or RAINDROP_TOKEN for the default profile
+// 2. Read-only token file (RAINDROP_TOKEN_FILE or Docker secret, default profile only)
+// 3. Previously saved OAuth token, refreshed if expired
+// An error is returned if there is no token and no way to obtain one.
+func findToken(cfg *config.Config, profileName string) (*tokenSource, error) {
+	profile, err := auth.LoadProfile(profileName)
+	if err != nil {
+		return nil, err
+	}
+	src := &tokenSource{Profile: profile}
+
+	// Priority 1: Direct token from profile settings or environment
+	if profile.Settings.Token != "" {
+		src.Description = "profile settings " + filepath.Join(profile.Dir, "settings.json")
+		src.Token = &auth.TokenData{AccessToken: profile.Settings.Token}
+		return src, nil
+	}
+	if token := cfg.Auth.Token; token != "" && profile.Name == auth.DefaultProfile {
+		src.Description = "configured token (RAINDROP_TOKEN or config file)"
+		src.Token = &auth.TokenData{AccessToken: token}
+		return src, nil
+	}
+
+	src.OAuth = oauthConfigFor(cfg, profile)
+	if src.Store, err = newTokenStore(cfg, profile); err != nil {
+		return nil, err
+	}
+	src.Description = src.Store.Location()
+
+	// Priority 2/3: Token file, refreshing it under the store lock if expired
+	token, err := auth.RefreshIfExpired(context.Background(), src.Store, src.OAuth)
+	if err != nil {
+		if _, ok := src.Store.(*auth.ReadOnlyStore); ok || src.OAuth == nil {
+			return nil, err
+		}
+		slog.Warn("Saved token unusable, starting a new authorization", "profile", profile.Name, "error", err)
+	} else if token != nil && token.AccessToken != "" {
+		src.Token = token
+		return src, nil
+	}
+
+	if _, ok := src.Store.(*auth.ReadOnlyStore); ok {
+		return nil, fmt.Errorf("token file %s is empty", src.Store.Location())
+	}
+	if src.OAuth == nil {
+		if profile.Name != auth.DefaultProfile {
+			return nil, fmt.Errorf("profile %q has no token or OAuth credentials in %s", profile.Name, filepath.Join(profile.Dir, "settings.json"))
+		}
+		return nil, fmt.Errorf("no authentication configured. Set RAINDROP_TOKEN, RAINDROP_TOKEN_FILE or RAINDROP_CLIENT_ID + RAINDROP_CLIENT_SECRET, or run 'raindrop-mcp login'")
+	}
+
+	return src, nil
+}
+
+// getAccessToken retrieves the access token for a profile. If no usable
+// token is saved it starts the OAuth2 flow when interactive is set, and
+// fails otherwise.
+func getAccessToken(cfg *config.Config, profileName string, interactive bool) (string, error) {
+	src, err := findToken(cfg, profileName)
+	if err != nil {
+		return "", err
+	}
+
+	if src.OAuth != nil {
+		logging.AddSecret(src.OAuth.ClientSecret)
+	}
+	if src.Token != nil {
+		logging.AddSecret(src.Token.AccessToken, src.Token.RefreshToken)
+		slog.Info("Using saved token", "profile", src.Profile.Name, "source", src.Description)
+		return src.Token.AccessToken, nil
+	}
+
+	if !interactive {
+		return "", fmt.Errorf("profile %q is not logged in; run 'raindrop-mcp login --profile %s' first", src.Profile.Name, src.Profile.Name)
+	}
+	token, err := runOAuthFlow(src.OAuth, src.Store)
+	if err != nil {
+		return "", err
+	}
+	logging.AddSecret(token.AccessToken, token.RefreshToken)
+	return token.AccessToken, nil
+}
+
+// runOAuthFlow authorizes in the browser and saves the new token
+func runOAuthFlow(config *auth.OAuthConfig, store auth.TokenStore) (*auth.TokenData, error) {
+	slog.Info("Starting OAuth authorization flow")
+	token, err := auth.StartOAuthFlow(context.Background(), config)
+	if err != nil {
+		return nil, fmt.Errorf("OAuth flow failed: %w", err)
+	}
+
+	// Save the new token
+	if err := store.Save(token); err != nil {
+		slog.Warn("Failed to save token", "location", store.Location(), "error", err)
+	}
+
+	slog.Info("OAuth authorization successful")
+	return token, nil
+}
+
+// newTokenStore selects where a profile's OAuth tokens are kept:
+// the configured token file or a Docker secret (read-only, default profile only),
+// an encrypted file when a passphrase is configured, otherwise token.json
+func newTokenStore(cfg *config.Config, profile *auth.Profile) (auth.TokenStore, error) {
+	if profile.Name == auth.DefaultProfile {
+		if path := cfg.Auth.TokenFile; path != "" {
+			return auth.NewReadOnlyStore(path), nil
+		}
+		if _, err := os.Stat(auth.DockerSecretPath); err == nil {
+			return auth.NewReadOnlyStore(auth.DockerSecretPath), nil
+		}
+	}
+
+	return profile.TokenStore(cfg.Auth.TokenPassphrase)
+}
+
+// oauthConfigFor returns the OAuth config for a profile, preferring the
+// profile's own app over the configured one. Returns nil if neither is set.
+func oauthConfigFor(cfg *config.Config, profile *auth.Profile) *auth.OAuthConfig {
+	clientID, clientSecret := profile.Settings.ClientID, profile.Settings.ClientSecret
+	if clientID == "" || clientSecret == "" {
+		clientID, clientSecret = cfg.Auth.ClientID, cfg.Auth.ClientSecret
+	}
+	if clientID == "" || clientSecret == "" {
+		return nil
+	}
+
+	return &auth.OAuthConfig{
+		ClientID:     clientID,
+		ClientSecret: clientSecret,
+		RedirectPort: cfg.Auth.RedirectPort,
+		RedirectPath: cfg.Auth.RedirectPath,
+		DisablePKCE:  cfg.Auth.PKCE != nil && !*cfg.Auth.PKCE,
+	}
+}
+
+// newClient creates an API client using the configured API settings
+func newClient(cfg *config.Config, token string) *api.Client {
+	return api.NewClientWithOptions(token, clientOptions(cfg))
+}
+
+// clientOptions returns the API client settings from the configuration
+func clientOptions(cfg *config.Config) api.Options {
+	opts := api.Options{
+		BaseURL: cfg.APIBaseURL,
+		Timeout: time.Duration(cfg.Timeout),
+	}
+	if cfg.Cache.Enabled {
+		opts.CacheTTL = time.Duration(cfg.Cache.TTL)
+	}
+	return opts
+}
@@ -0,0 +1,419 @@
+package tools
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+
+	"raindrop-mcp/api"
+	"raindrop-mcp/collections"
+	"raindrop-mcp/policy"
+	"raindrop-mcp/render"
+	"raindrop-mcp/types"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+)
+
+const (
+	// exportPageSize is the largest page the Raindrop API returns
+	exportPageSize = 50
+	// defaultExportLimit caps exports when no limit is given
+	defaultExportLimit = 1000
+	// maxFetchIDs caps the bookmarks get-bookmarks fetches in one call
+	maxFetchIDs = 100
+	// fetchConcurrency bounds the requests get-bookmarks makes at once
+	fetchConcurrency = 5
+)
+
+// RegisterBulkTools registers tools that work through many bookmarks.
+// They report progress and stop cleanly when the call is cancelled.
+func RegisterBulkTools(r *Registry) {
+	addTool(r, policy.Bookmarks, &mcp.Tool{
+		Name:        "export-bookmarks",
+		Description: "Export all bookmarks in a collection (0 for all) page by page, with progress reporting",
+		Annotations: &mcp.ToolAnnotations{
+			Title:           "Export Bookmarks",
+			ReadOnlyHint:    true,
+			DestructiveHint: boolPtr(false),
+			IdempotentHint:  true,
+			OpenWorldHint:   boolPtr(false),
+		},
+	}, func(ctx context.Context, req *mcp.CallToolRequest, client *api.Client, input ExportBookmarksInput) (*ExportOutput, string, error) {
+		limit := input.Limit
+		if limit <= 0 {
+			limit = defaultExportLimit
+		}
+		// Reject bad format or field names before fetching anything
+		opts := r.renderOptions(input.Listing)
+		if _, err := render.Bookmarks(nil, 0, opts); err != nil {
+			return nil, "", err
+		}
+
+		collection, err := collections.NewResolver(client).Resolve(ctx, input.Collection)
+		if err != nil {
+			return nil, "", err
+		}
+		bookmarks, result, err := exportBookmarks(ctx, newProgress(ctx, req), client, collection, input.Query, limit)
+		if err != nil {
+			return nil, "", err
+		}
+
+		text, err := render.Bookmarks(bookmarks, len(bookmarks), opts)
+		if err != nil {
+			return nil, "", err
+		}
+		text = strings.TrimRight(text, "\n") + "\n\n" + result.Summary()
+		return &ExportOutput{Bookmarks: bookmarks, Job: result}, text, nil
+	})
+
+	// get-bookmarks
+	addTool(r, policy.Bookmarks, &mcp.Tool{
+		Name:        "get-bookmarks",
+		Description: fmt.Sprintf("Get up to %d bookmarks by ID in one call, in the order given; IDs that do not exist are listed separately", maxFetchIDs),
+		Annotations: &mcp.ToolAnnotations{
+			Title:           "Get Bookmarks",
+			ReadOnlyHint:    true,
+			DestructiveHint: boolPtr(false),
+			IdempotentHint:  true,
+			OpenWorldHint:   boolPtr(false),
+		},
+	}, func(ctx context.Context, req *mcp.CallToolRequest, client *api.Client, input GetBookmarksInput) (*BatchBookmarksOutput, string, error) {
+		ids := uniqueIDs(input.IDs)
+		switch {
+		case len(ids) == 0:
+			return nil, "", errors.New("no bookmark ids given")
+		case len(ids) > maxFetchIDs:
+			return nil, "", fmt.Errorf("too many ids: %d given, at most %d per call", len(ids), maxFetchIDs)
+		}
+		opts := r.renderOptions(input.Listing)
+		if _, err := render.Bookmarks(nil, 0, opts); err != nil {
+			return nil, "", err
+		}
+
+		fetched, errs := fetchBookmarks(ctx, newProgress(ctx, req), client, ids)
+
+		// IDs cut off by cancellation are left over rather than failed
+		out := &BatchBookmarksOutput{Bookmarks: []types.Raindrop{}, Missing: []int{}, Job: &jobResult{Cancelled: ctx.Err() != nil}}
+		for i, id := range ids {
+			switch {
+			case errs[i] == nil:
+				out.Bookmarks = append(out.Bookmarks, *fetched[i])
+				out.Job.Done++
+			case api.IsNotFound(errs[i]):
+				out.Missing = append(out.Missing, id)
+				out.Job.Done++
+			case out.Job.Cancelled && errors.Is(errs[i], ctx.Err()):
+				out.Job.Remaining++
+			default:
+				out.Job.fail(fmt.Sprintf("bookmark %d", id), errs[i])
+			}
+		}
+
+		text, err := render.Bookmarks(out.Bookmarks, len(out.Bookmarks), opts)
+		if err != nil {
+			return nil, "", err
+		}
+		text = strings.TrimRight(text, "\n") + "\n"
+		if len(out.Missing) > 0 {
+			missing := make([]string, len(out.Missing))
+			for i, id := range out.Missing {
+				missing[i] = strconv.Itoa(id)
+			}
+			text += "\nNot found: " + strings.Join(missing, ", ") + "\n"
+		}
+		return out, text + "\n" + out.Job.Summary(), nil
+	})
+
+	// add-tags
+	addTool(r, policy.Bookmarks, &mcp.Tool{
+		Name:        "add-tags",
+		Description: "Add tags to many bookmarks, given by ID or selected with a search query, keeping their other tags",
+		Annotations: &mcp.ToolAnnotations{
+			Title:           "Add Tags",
+			ReadOnlyHint:    false,
+			DestructiveHint: boolPtr(false),
+			IdempotentHint:  true,
+			OpenWorldHint:   boolPtr(false),
+		},
+	}, func(ctx context.Context, req *mcp.CallToolRequest, client *api.Client, input AddTagsInput) (*TagEditOutput, string, error) {
+		return bulkEditTags(ctx, req, client, input.TagTargets, input.Tags, nil)
+	})
+
+	// remove-tags
+	addTool(r, policy.Bookmarks, &mcp.Tool{
+		Name:        "remove-tags",
+		Description: "Remove tags from many bookmarks, given by ID or selected with a search query, keeping their other tags",
+		Annotations: &mcp.ToolAnnotations{
+			Title:           "Remove Tags",
+			ReadOnlyHint:    false,
+			DestructiveHint: boolPtr(true),
+			IdempotentHint:  true,
+			OpenWorldHint:   boolPtr(false),
+		},
+	}, func(ctx context.Context, req *mcp.CallToolRequest, client *api.Client, input RemoveTagsInput) (*TagEditOutput, string, error) {
+		return bulkEditTags(ctx, req, client, input.TagTargets, nil, input.Tags)
+	})
+}
+
+type ExportBookmarksInput struct {
+	Collection collections.Ref `json:"collection,omitempty" jsonschema:"Collection ID, title, path, unsorted or all (default all)"`
+	Query      string          `json:"query,omitempty" jsonschema:"Optional search query to filter bookmarks"`
+	Limit      int             `json:"limit,omitempty" jsonschema:"Maximum bookmarks to export (default 1000)"`
+	Listing
+}
+
+type GetBookmarksInput struct {
+	IDs []int `json:"ids" jsonschema:"Bookmark IDs, at most 100; duplicates are returned once"`
+	Listing
+}
+
+// uniqueIDs drops repeated IDs, keeping the first of each
+func uniqueIDs(ids []int) []int {
+	seen := make(map[int]bool, len(ids))
+	unique := make([]int, 0, len(ids))
+	for _, id := range ids {
+		if !seen[id] {
+			seen[id] = true
+			unique = append(unique, id)
+		}
+	}
+	return unique
+}
+
+// fetchBookmarks gets bookmarks by ID, at most fetchConcurrency at a time.
+// Results and errors are indexed like ids; IDs not started before the call
+// is cancelled get the context's error.
+func fetchBookmarks(ctx context.Context, p *progress, client *api.Client, ids []int) ([]*types.Raindrop, []error) {
+	bookmarks := make([]*types.Raindrop, len(ids))
+	errs := make([]error, len(ids))
+
+	var wg sync.WaitGroup
+	var mu sync.Mutex
+	done := 0
+	slots := make(chan struct{}, fetchConcurrency)
+	for i, id := range ids {
+		if ctx.Err() != nil {
+			errs[i] = ctx.Err()
+			continue
+		}
+		select {
+		case slots <- struct{}{}:
+		case <-ctx.Done():
+			errs[i] = ctx.Err()
+			continue
+		}
+
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			defer func() { <-slots }()
+			bookmarks[i], errs[i] = client.GetRaindrop(ctx, id)
+
+			mu.Lock()
+			defer mu.Unlock()
+			done++
+			p.report(done, len(ids), fmt.Sprintf("bookmark %d", id), false)
+		}()
+	}
+	wg.Wait()
+
+	p.report(done, len(ids), "finished", true)
+	return bookmarks, errs
+}
+
+// TagTargets selects the bookmarks a bulk tag edit changes
+type TagTargets struct {
+	IDs        []int           `json:"ids,omitempty" jsonschema:"Bookmark IDs to change"`
+	Query      string          `json:"query,omitempty" jsonschema:"Search query selecting the bookmarks to change, instead of ids"`
+	Collection collections.Ref `json:"collection,omitempty" jsonschema:"Collection to search with query: ID, title, path, unsorted or all"`
+	Limit      int             `json:"limit,omitempty" jsonschema:"Maximum bookmarks to change (default 1000)"`
+}
+
+type AddTagsInput struct {
+	TagTargets
+	Tags []string `json:"tags" jsonschema:"Tags to add; tags already present in any letter case are kept as they are"`
+}
+
+type RemoveTagsInput struct {
+	TagTargets
+	Tags []string `json:"tags" jsonschema:"Tags to remove, ignoring case"`
+}
+
+// bulkEditTags adds and removes tags on each targeted bookmark. Every
+// bookmark is re-read just before it is written, so tags added elsewhere in
+// the meantime are kept.
+func bulkEditTags(ctx context.Context, req *mcp.CallToolRequest, client *api.Client, targets TagTargets, add, remove []string) (*TagEditOutput, string, error) {
+	if len(add) == 0 && len(remove) == 0 {
+		return nil, "", errors.New("no tags given")
+	}
+	ids, err := targetIDs(ctx, client, targets)
+	if err != nil {
+		return nil, "", err
+	}
+	if len(ids) == 0 {
+		return &TagEditOutput{Results: []TagEditResult{}, Job: &jobResult{}}, "No bookmarks matched.", nil
+	}
+
+	results := []TagEditResult{}
+	job := runJob(ctx, req, ids, func(id int) string {
+		return fmt.Sprintf("bookmark %d", id)
+	}, func(ctx context.Context, id int) error {
+		result := TagEditResult{ID: id}
+		err := editBookmarkTags(ctx, client, id, add, remove, &result)
+		if err != nil {
+			result.Error = err.Error()
+		}
+		results = append(results, result)
+		return err
+	})
+
+	var sb strings.Builder
+	for _, res := range results {
+		switch {
+		case res.Error != "":
+			// Listed with the job summary
+		case !res.Changed:
+			sb.WriteString(fmt.Sprintf("- %d %q: unchanged (%s)\n", res.ID, res.Title, formatValue(res.After)))
+		default:
+			sb.WriteString(fmt.Sprintf("- %d %q: %s → %s\n", res.ID, res.Title, formatValue(res.Before), formatValue(res.After)))
+		}
+	}
+	sb.WriteString("\n" + job.Summary())
+	return &TagEditOutput{Results: results, Job: job}, sb.String(), nil
+}
+
+// editBookmarkTags applies a tag edit to one bookmark, filling in result
+func editBookmarkTags(ctx context.Context, client *api.Client, id int, add, remove []string, result *TagEditResult) error {
+	current, err := client.GetRaindropFresh(ctx, id)
+	if err != nil {
+		return err
+	}
+	tags := editTags(current.Tags, add, remove)
+	result.Title = current.Title
+	result.Before = append([]string{}, current.Tags...)
+	result.After = tags
+	if slices.Equal(result.Before, tags) {
+		return nil
+	}
+	if _, err := client.UpdateRaindrop(ctx, id, types.UpdateRaindropRequest{Tags: &tags}); err != nil {
+		return err
+	}
+	result.Changed = true
+	return nil
+}
+
+// targetIDs resolves the bookmarks a bulk edit applies to. Explicit ids are
+// deduplicated and held to the same limit as a search.
+func targetIDs(ctx context.Context, client *api.Client, targets TagTargets) ([]int, error) {
+	limit := targets.Limit
+	if limit <= 0 {
+		limit = defaultExportLimit
+	}
+	searching := targets.Query != "" || targets.Collection != ""
+	switch {
+	case len(targets.IDs) > 0 && searching:
+		return nil, errors.New("give either ids or a query/collection, not both")
+	case len(targets.IDs) > 0:
+		ids := uniqueIDs(targets.IDs)
+		if len(ids) > limit {
+			return nil, fmt.Errorf("too many ids: %d given, at most %d per call", len(ids), limit)
+		}
+		return ids, nil
+	case !searching:
+		return nil, errors.New("give bookmark ids, a query or a collection")
+	}
+
+	// Matching is quick, so only the edits themselves report progress
+	collection, err := collections.NewResolver(client).Resolve(ctx, targets.Collection)
+	if err != nil {
+		return nil, err
+	}
+	bookmarks, result, err := exportBookmarks(ctx, &progress{}, client, collection, targets.Query, limit)
+	if err != nil {
+		return nil, err
+	}
+	if result.Cancelled || result.Failed > 0 {
+		return nil, fmt.Errorf("failed to find bookmarks: %s", strings.TrimSpace(result.Summary()))
+	}
+	ids := make([]int, len(bookmarks))
+	for i, b := range bookmarks {
+		ids[i] = b.ID
+	}
+	return ids, nil
+}
+
+// editTags adds and removes tags, comparing them case-insensitively. Existing
+// tags keep their order and spelling, duplicates are dropped and new tags
+// are appended. The result is never nil, so it clears the tags when empty.
+func editTags(current, add, remove []string) []string {
+	removed := make(map[string]bool, len(remove))
+	for _, t := range remove {
+		removed[strings.ToLower(strings.TrimSpace(t))] = true
+	}
+
+	tags := []string{}
+	seen := make(map[string]bool)
+	for _, t := range slices.Concat(current, add) {
+		t = strings.TrimSpace(t)
+		key := strings.ToLower(t)
+		if t == "" || seen[key] || removed[key] {
+			continue
+		}
+		seen[key] = true
+		tags = append(tags, t)
+	}
+	return tags
+}
+
+// exportBookmarks fetches bookmarks page by page until limit is reached,
+// the collection is exhausted or the call is cancelled
+func exportBookmarks(ctx context.Context, p *progress, client *api.Client, collection int, query string, limit int) ([]types.Raindrop, *jobResult, error) {
+	result := &jobResult{}
+	var bookmarks []types.Raindrop
+
+	total := 0
+	for page := 0; ; page++ {
+		if ctx.Err() != nil {
+			result.Cancelled = true
+			break
+		}
+
+		resp, err := client.SearchRaindrops(ctx, query, collection, page, exportPageSize, nil)
+		if err != nil {
+			// A request cut off by cancellation is not a failure
+			if ctx.Err() != nil {
+				result.Cancelled = true
+				break
+			}
+			if page == 0 {
+				return nil, nil, fmt.Errorf("failed to export bookmarks: %w", err)
+			}
+			result.fail(fmt.Sprintf("page %d", page+1), err)
+			break
+		}
+		if page == 0 {
+			total = min(resp.Count, limit)
+		}
+
+		for _, item := range resp.Items {
+			if len(bookmarks) == total {
+				break
+			}
+			bookmarks = append(bookmarks, item)
+		}
+		result.Done = len(bookmarks)
+		p.report(result.Done, total, fmt.Sprintf("fetched page %d", page+1), false)
+
+		if len(resp.Items) < exportPageSize || len(bookmarks) >= total {
+			break
+		}
+	}
+
+	result.Remaining = max(total-result.Done, 0)
+	p.report(result.Done, total, result.state(), true)
+	return bookmarks, result, nil
+}
@@ -0,0 +1,106 @@
+package tools
+
+import (
+	"context"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestEditTags(t *testing.T) {
+	tests := []struct {
+		name                 string
+		current, add, remove []string
+		want                 []string
+	}{
+		{
+			name:    "add new tags",
+			current: []string{"go"},
+			add:     []string{"web", "api"},
+			want:    []string{"go", "web", "api"},
+		},
+		{
+			name:    "existing spelling kept",
+			current: []string{"Go", "Web"},
+			add:     []string{"go", "WEB", "api"},
+			want:    []string{"Go", "Web", "api"},
+		},
+		{
+			name:    "duplicates in current dropped",
+			current: []string{"go", "Go", " go "},
+			want:    []string{"go"},
+		},
+		{
+			name:    "duplicates in add dropped",
+			current: nil,
+			add:     []string{"Rust", "rust", "RUST"},
+			want:    []string{"Rust"},
+		},
+		{
+			name:    "remove ignores case and space",
+			current: []string{"Go", "web", "api"},
+			remove:  []string{" go", "API"},
+			want:    []string{"web"},
+		},
+		{
+			name:    "remove wins over add",
+			current: []string{"go"},
+			add:     []string{"web"},
+			remove:  []string{"WEB"},
+			want:    []string{"go"},
+		},
+		{
+			name:    "blank tags dropped",
+			current: []string{"", "go"},
+			add:     []string{"  "},
+			want:    []string{"go"},
+		},
+		{
+			name:    "removing every tag",
+			current: []string{"go"},
+			remove:  []string{"go"},
+			want:    []string{},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := editTags(tt.current, tt.add, tt.remove)
+			if got == nil {
+				t.Fatal("editTags returned nil; an empty list is needed to clear tags")
+			}
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("editTags(%q, %q, %q) = %q, want %q", tt.current, tt.add, tt.remove, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestTargetIDs(t *testing.T) {
+	tests := []struct {
+		name    string
+		targets TagTargets
+		want    []int
+		wantErr string
+	}{
+		{name: "ids deduplicated", targets: TagTargets{IDs: []int{3, 1, 3, 2, 1}}, want: []int{3, 1, 2}},
+		{name: "duplicates do not count towards the limit", targets: TagTargets{IDs: []int{1, 1, 1}, Limit: 1}, want: []int{1}},
+		{name: "too many ids", targets: TagTargets{IDs: []int{1, 2, 3}, Limit: 2}, wantErr: "too many ids: 3 given, at most 2"},
+		{name: "ids and query", targets: TagTargets{IDs: []int{1}, Query: "go"}, wantErr: "not both"},
+		{name: "nothing", targets: TagTargets{}, wantErr: "give bookmark ids"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// None of these cases search, so no client is needed
+			got, err := targetIDs(context.Background(), nil, tt.targets)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("targetIDs() = %v, %v; want error containing %q", got, err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil || !slices.Equal(got, tt.want) {
+				t.Errorf("targetIDs() = %v, %v; want %v", got, err, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,214 @@
+package tools
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+)
+
+// confirmTTL bounds how long a dry-run confirm token stays valid
+const confirmTTL = 5 * time.Minute
+
+// confirmTokenField is the input field carrying a confirm token; it is
+// excluded when matching a token to the operation it was issued for
+const confirmTokenField = "confirm_token"
+
+// confirmNote is appended to the descriptions of tools that ask for confirmation
+const confirmNote = ". Shows the impact and asks the user to confirm first; clients without " +
+	"interactive confirmation get a dry-run preview and must call again with its confirm_token."
+
+// impact describes what a destructive operation will change
+type impact struct {
+	// Summary is one line such as `Delete collection "Work" (ID 5)`
+	Summary string `json:"summary"`
+	// Details lists the affected items
+	Details []string `json:"details"`
+}
+
+func (i *impact) String() string {
+	var sb strings.Builder
+	sb.WriteString(i.Summary + "\n")
+	for _, d := range i.Details {
+		sb.WriteString("- " + d + "\n")
+	}
+	return sb.String()
+}
+
+// pendingConfirm is an operation previewed by a dry run
+type pendingConfirm struct {
+	session   *mcp.ServerSession
+	operation string
+	expires   time.Time
+}
+
+// confirmations holds confirm tokens issued by dry runs
+type confirmations struct {
+	mu      sync.Mutex
+	pending map[string]pendingConfirm
+}
+
+func newConfirmations() *confirmations {
+	return &confirmations{pending: make(map[string]pendingConfirm)}
+}
+
+// issue returns a single-use token approving operation for ss
+func (c *confirmations) issue(ss *mcp.ServerSession, operation string) (string, error) {
+	buf := make([]byte, 12)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	token := hex.EncodeToString(buf)
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	now := time.Now()
+	for t, p := range c.pending {
+		if now.After(p.expires) {
+			delete(c.pending, t)
+		}
+	}
+	c.pending[token] = pendingConfirm{session: ss, operation: operation, expires: now.Add(confirmTTL)}
+	return token, nil
+}
+
+// redeem consumes token if it approves operation for ss
+func (c *confirmations) redeem(ss *mcp.ServerSession, operation, token string) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	p, ok := c.pending[token]
+	if !ok || p.session != ss || p.operation != operation || time.Now().After(p.expires) {
+		return false
+	}
+	delete(c.pending, token)
+	return true
+}
+
+// errNotConfirmed means the user declined or has yet to confirm; the output
+// returned alongside it is the tool's result
+var errNotConfirmed = errors.New("not confirmed")
+
+// confirm gets the user's approval for a destructive operation described by
+// impact. Clients that support elicitation are asked directly. Otherwise the
+// first call returns a preview with a confirm token, and a second call with
+// the same input plus that token proceeds.
+//
+// It returns nil when the operation may proceed, or errNotConfirmed with the
+// output to return instead.
+func (r *Registry) confirm(ctx context.Context, req *mcp.CallToolRequest, input any, token string, imp *impact) (*ChangeOutput, error) {
+	operation, err := operationKey(req.Params.Name, input)
+	if err != nil {
+		return nil, err
+	}
+
+	if token != "" {
+		if !r.confirms.redeem(req.Session, operation, token) {
+			return nil, fmt.Errorf("confirm token is invalid, expired or was issued for different input; call %s again without %s for a new preview", req.Params.Name, confirmTokenField)
+		}
+		slog.InfoContext(ctx, "Destructive operation confirmed by token", "tool", req.Params.Name)
+		return nil, nil
+	}
+
+	if supportsElicitation(req.Session) {
+		approved, err := elicitConfirmation(ctx, req.Session, imp)
+		if err == nil {
+			if !approved {
+				return &ChangeOutput{Message: "Cancelled, nothing was changed.\n\n" + imp.String(), Impact: imp}, errNotConfirmed
+			}
+			slog.InfoContext(ctx, "Destructive operation confirmed by user", "tool", req.Params.Name)
+			return nil, nil
+		}
+		// Fall back to a confirm token if the client could not ask
+		slog.WarnContext(ctx, "Elicitation failed, falling back to confirm token", "tool", req.Params.Name, "error", err)
+	}
+
+	token, err = r.confirms.issue(req.Session, operation)
+	if err != nil {
+		return nil, fmt.Errorf("failed to issue confirm token: %w", err)
+	}
+	return &ChangeOutput{
+		Message: fmt.Sprintf("Dry run, nothing was changed.\n\n%s\nTo proceed, call %s again with the same arguments and %s: %q (valid for %s).",
+			imp.String(), req.Params.Name, confirmTokenField, token, confirmTTL),
+		ConfirmToken: token,
+		Impact:       imp,
+	}, errNotConfirmed
+}
+
+// supportsElicitation reports whether the client can show confirmation forms
+func supportsElicitation(ss *mcp.ServerSession) bool {
+	params := ss.InitializeParams()
+	return params != nil && params.Capabilities != nil && params.Capabilities.Elicitation != nil
+}
+
+// elicitConfirmation asks the user to approve imp
+func elicitConfirmation(ctx context.Context, ss *mcp.ServerSession, imp *impact) (bool, error) {
+	res, err := ss.Elicit(ctx, &mcp.ElicitParams{
+		Message: imp.String() + "\nDo you want to proceed?",
+		RequestedSchema: map[string]any{
+			"type": "object",
+			"properties": map[string]any{
+				"confirm": map[string]any{
+					"type":        "boolean",
+					"title":       "Proceed",
+					"description": "Carry out this change",
+				},
+			},
+		},
+	})
+	if err != nil {
+		return false, err
+	}
+	confirmed, _ := res.Content["confirm"].(bool)
+	return res.Action == "accept" && confirmed, nil
+}
+
+// operationKey identifies a tool call by name and input, ignoring the confirm token
+func operationKey(name string, input any) (string, error) {
+	data, err := json.Marshal(input)
+	if err != nil {
+		return "", err
+	}
+	var fields map[string]any
+	if err := json.Unmarshal(data, &fields); err != nil {
+		return "", err
+	}
+	delete(fields, confirmTokenField)
+
+	// Map keys marshal in sorted order, so equal inputs give equal keys
+	data, err = json.Marshal(fields)
+	if err != nil {
+		return "", err
+	}
+	sum := sha256.Sum256(append([]byte(name+"\x00"), data...))
+	return hex.EncodeToString(sum[:]), nil
+}
+
+// destructive runs a destructive operation once it is confirmed. describe
+// computes the impact shown to the user; do performs the operation and
+// returns a message describing the outcome.
+func (r *Registry) destructive(ctx context.Context, req *mcp.CallToolRequest, input any, token string, describe func() (*impact, error), do func() (string, error)) (*ChangeOutput, string, error) {
+	imp, err := describe()
+	if err != nil {
+		return nil, "", err
+	}
+	if out, err := r.confirm(ctx, req, input, token, imp); err != nil {
+		if errors.Is(err, errNotConfirmed) {
+			return out, out.Message, nil
+		}
+		return nil, "", err
+	}
+	message, err := do()
+	if err != nil {
+		return nil, "", err
+	}
+	return &ChangeOutput{Applied: true, Message: message, Impact: imp}, message, nil
+}
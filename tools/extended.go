@@ -2,244 +2,622 @@ package tools
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"net/url"
+	"regexp"
+	"slices"
 	"strings"
 
 	"raindrop-mcp/api"
+	"raindrop-mcp/collections"
+	"raindrop-mcp/policy"
+	"raindrop-mcp/render"
 	"raindrop-mcp/types"
 
 	"github.com/modelcontextprotocol/go-sdk/mcp"
 )
 
 // RegisterExtendedTools registers additional Raindrop tools
-func RegisterExtendedTools(server *mcp.Server, client *api.Client) {
+func RegisterExtendedTools(r *Registry) {
 	// --- Collections ---
 
-	mcp.AddTool(server, &mcp.Tool{
+	addTool(r, policy.Collections, &mcp.Tool{
 		Name:        "create-collection",
-		Description: "Create a new collection in Raindrop.io",
-	}, func(ctx context.Context, req *mcp.CallToolRequest, input CreateCollectionInput) (*mcp.CallToolResult, TextOutput, error) {
-		collection, err := client.CreateCollection(input.Title, input.Parent, input.Public)
+		Description: "Create a new collection in Raindrop.io, optionally setting its view, color, cover icon, sort position and expanded state",
+		Annotations: &mcp.ToolAnnotations{
+			Title:           "Create Collection",
+			ReadOnlyHint:    false,
+			DestructiveHint: boolPtr(false),
+			IdempotentHint:  false,
+			OpenWorldHint:   boolPtr(false),
+		},
+	}, func(ctx context.Context, req *mcp.CallToolRequest, client *api.Client, input CreateCollectionInput) (*CollectionOutput, string, error) {
+		if err := input.Appearance.validate(); err != nil {
+			return nil, "", err
+		}
+		parent, err := collections.NewResolver(client).Resolve(ctx, input.Parent)
+		if err != nil {
+			return nil, "", err
+		}
+		reqBody := types.CreateCollectionRequest{
+			Title:    input.Title,
+			Public:   input.Public,
+			View:     input.View,
+			Color:    input.Color,
+			Sort:     input.Sort,
+			Expanded: input.Expanded,
+		}
+		if parent > 0 {
+			reqBody.Parent = &types.CollectionRef{ID: parent}
+		}
+		if input.Cover != nil && *input.Cover != "" {
+			reqBody.Cover = []string{*input.Cover}
+		}
+		collection, err := client.CreateCollectionFrom(ctx, reqBody)
 		if err != nil {
-			return nil, TextOutput{}, fmt.Errorf("failed to create collection: %w", err)
+			return nil, "", fmt.Errorf("failed to create collection: %w", err)
 		}
-		return nil, TextOutput{Text: formatCollection(collection)}, nil
+		return &CollectionOutput{Collection: *collection}, formatCollection(collection), nil
 	})
 
-	mcp.AddTool(server, &mcp.Tool{
+	addTool(r, policy.Collections, &mcp.Tool{
 		Name:        "get-collection",
 		Description: "Get a collection by its ID",
-	}, func(ctx context.Context, req *mcp.CallToolRequest, input GetCollectionInput) (*mcp.CallToolResult, TextOutput, error) {
-		collection, err := client.GetCollection(input.ID)
+		Annotations: &mcp.ToolAnnotations{
+			Title:           "Get Collection",
+			ReadOnlyHint:    true,
+			DestructiveHint: boolPtr(false),
+			IdempotentHint:  true,
+			OpenWorldHint:   boolPtr(false),
+		},
+	}, func(ctx context.Context, req *mcp.CallToolRequest, client *api.Client, input GetCollectionInput) (*CollectionOutput, string, error) {
+		id, err := collections.NewResolver(client).Resolve(ctx, input.ID)
+		if err != nil {
+			return nil, "", err
+		}
+		collection, err := client.GetCollection(ctx, id)
 		if err != nil {
-			return nil, TextOutput{}, fmt.Errorf("failed to get collection: %w", err)
+			return nil, "", fmt.Errorf("failed to get collection: %w", err)
 		}
-		return nil, TextOutput{Text: formatCollection(collection)}, nil
+		return &CollectionOutput{Collection: *collection}, formatCollection(collection), nil
 	})
 
-	mcp.AddTool(server, &mcp.Tool{
+	addTool(r, policy.Collections, &mcp.Tool{
 		Name:        "update-collection",
-		Description: "Update an existing collection",
-	}, func(ctx context.Context, req *mcp.CallToolRequest, input UpdateCollectionInput) (*mcp.CallToolResult, TextOutput, error) {
-		var publicPtr *bool
-		if input.Public != nil {
-			publicPtr = input.Public
+		Description: "Update an existing collection: its title, parent, visibility, view, color, cover icon, sort position or expanded state",
+		Annotations: &mcp.ToolAnnotations{
+			Title:           "Update Collection",
+			ReadOnlyHint:    false,
+			DestructiveHint: boolPtr(true),
+			IdempotentHint:  true,
+			OpenWorldHint:   boolPtr(false),
+		},
+	}, func(ctx context.Context, req *mcp.CallToolRequest, client *api.Client, input UpdateCollectionInput) (*CollectionOutput, string, error) {
+		if err := input.Appearance.validate(); err != nil {
+			return nil, "", err
+		}
+		resolver := collections.NewResolver(client)
+		id, err := resolver.Resolve(ctx, input.ID)
+		if err != nil {
+			return nil, "", err
 		}
-		var parentPtr *int
-		if input.Parent != 0 {
-			parentPtr = &input.Parent
+		// Parent changes go through the same checks as move-collection
+		moves := false
+		parent := 0
+		if input.Parent != "" {
+			if parent, err = resolver.Resolve(ctx, input.Parent); err != nil {
+				return nil, "", err
+			}
+			index, err := resolver.Index(ctx)
+			if err != nil {
+				return nil, "", err
+			}
+			if _, moves, err = movePlan(index, id, parent); err != nil {
+				return nil, "", err
+			}
 		}
-		collection, err := client.UpdateCollection(input.ID, input.Title, publicPtr, parentPtr)
+		patch := types.UpdateCollectionRequest{
+			Title:    input.Title,
+			Public:   input.Public,
+			View:     input.View,
+			Color:    input.Color,
+			Sort:     input.Sort,
+			Expanded: input.Expanded,
+		}
+		if input.Cover != nil {
+			// An empty cover list removes the cover
+			cover := []string{}
+			if *input.Cover != "" {
+				cover = append(cover, *input.Cover)
+			}
+			patch.Cover = &cover
+		}
+		if patch == (types.UpdateCollectionRequest{}) && !moves {
+			if input.Parent != "" {
+				return nil, "", errors.New("nothing to update: the collection already has that parent")
+			}
+			return nil, "", errors.New("nothing to update: give at least one field to change")
+		}
+
+		var collection *types.Collection
+		if patch != (types.UpdateCollectionRequest{}) {
+			if collection, err = client.PatchCollection(ctx, id, patch); err != nil {
+				return nil, "", fmt.Errorf("failed to update collection: %w", err)
+			}
+		}
+		if moves {
+			if collection, err = client.MoveCollection(ctx, id, parent); err != nil {
+				return nil, "", fmt.Errorf("failed to move collection: %w", err)
+			}
+		}
+		return &CollectionOutput{Collection: *collection}, formatCollection(collection), nil
+	})
+
+	addTool(r, policy.Collections, &mcp.Tool{
+		Name:        "search-covers",
+		Description: "Search Raindrop's icon library for collection cover icons; pass an icon URL as cover to create-collection or update-collection",
+		Annotations: &mcp.ToolAnnotations{
+			Title:           "Search Cover Icons",
+			ReadOnlyHint:    true,
+			DestructiveHint: boolPtr(false),
+			IdempotentHint:  true,
+			OpenWorldHint:   boolPtr(false),
+		},
+	}, func(ctx context.Context, req *mcp.CallToolRequest, client *api.Client, input SearchCoversInput) (*CoversOutput, string, error) {
+		query := strings.TrimSpace(input.Query)
+		if query == "" {
+			return nil, "", errors.New("query is required")
+		}
+		groups, err := client.SearchCovers(ctx, query)
 		if err != nil {
-			return nil, TextOutput{}, fmt.Errorf("failed to update collection: %w", err)
+			return nil, "", fmt.Errorf("failed to search covers: %w", err)
 		}
-		return nil, TextOutput{Text: formatCollection(collection)}, nil
+
+		var sb strings.Builder
+		for _, g := range groups {
+			if len(g.Icons) == 0 {
+				continue
+			}
+			sb.WriteString(fmt.Sprintf("## %s\n", g.Title))
+			for _, icon := range g.Icons {
+				sb.WriteString(fmt.Sprintf("- %s\n", icon.PNG))
+			}
+			sb.WriteString("\n")
+		}
+		if sb.Len() == 0 {
+			sb.WriteString(fmt.Sprintf("No cover icons found for %q.", query))
+		}
+		return &CoversOutput{Covers: groups}, sb.String(), nil
 	})
 
-	mcp.AddTool(server, &mcp.Tool{
+	addTool(r, policy.Collections, &mcp.Tool{
 		Name:        "delete-collection",
-		Description: "Delete a collection",
-	}, func(ctx context.Context, req *mcp.CallToolRequest, input DeleteCollectionInput) (*mcp.CallToolResult, TextOutput, error) {
-		err := client.DeleteCollection(input.ID)
+		Description: "Delete a collection. A collection with bookmarks or subcollections is only deleted with a mode: \"move\" moves its contents to move_to first, \"recursive\" deletes everything in it; the default, \"abort\", lists the contents and deletes nothing" + confirmNote,
+		Annotations: &mcp.ToolAnnotations{
+			Title:           "Delete Collection",
+			ReadOnlyHint:    false,
+			DestructiveHint: boolPtr(true),
+			IdempotentHint:  true,
+			OpenWorldHint:   boolPtr(false),
+		},
+	}, func(ctx context.Context, req *mcp.CallToolRequest, client *api.Client, input DeleteCollectionInput) (*ChangeOutput, string, error) {
+		mode := input.Mode
+		if mode == "" {
+			mode = deleteAbort
+		}
+		if !slices.Contains(deleteModes, mode) {
+			return nil, "", fmt.Errorf("unknown mode %q; valid modes are %s", mode, strings.Join(deleteModes, ", "))
+		}
+		if (mode == deleteMove) != (input.MoveTo != "") {
+			return nil, "", errors.New("move_to is required with mode \"move\" and only used with it")
+		}
+
+		resolver := collections.NewResolver(client)
+		index, collection, err := resolveUserCollection(ctx, resolver, input.ID)
+		if err != nil {
+			return nil, "", err
+		}
+		contents, err := loadContents(ctx, client, index, collection.FullID)
 		if err != nil {
-			return nil, TextOutput{}, fmt.Errorf("failed to delete collection: %w", err)
+			return nil, "", err
 		}
-		return nil, TextOutput{Text: fmt.Sprintf("Collection %d deleted successfully", input.ID)}, nil
+		target := 0
+		if mode == deleteMove {
+			if target, err = resolver.Resolve(ctx, input.MoveTo); err != nil {
+				return nil, "", err
+			}
+			if err := contents.checkTarget(index, target); err != nil {
+				return nil, "", err
+			}
+		}
+
+		if mode == deleteAbort && !contents.empty() {
+			// The inventory is what a recursive delete would remove
+			imp := deleteCollectionImpact(index, contents, deleteRecursive, 0)
+			imp.Summary = fmt.Sprintf("Not deleted: %s (ID: %d) has %s and %s",
+				pathLabel(index, collection.FullID), collection.FullID,
+				render.CountNoun(len(contents.node.Children), "subcollection"), render.CountNoun(len(contents.bookmarks[collection.FullID]), "bookmark"))
+			message := imp.String() + "\nTo delete it, call again with mode \"move\" and move_to, or with mode \"recursive\"."
+			return &ChangeOutput{Message: message, Impact: imp}, message, nil
+		}
+
+		var job *jobResult
+		out, text, err := r.destructive(ctx, req, input, input.ConfirmToken, func() (*impact, error) {
+			return deleteCollectionImpact(index, contents, mode, target), nil
+		}, func() (string, error) {
+			if mode == deleteMove && !contents.empty() {
+				job = contents.moveTo(ctx, req, client, target)
+				if job.Failed > 0 || job.Remaining > 0 {
+					return "", fmt.Errorf("collection %d was not deleted because not all of its contents could be moved: %s", collection.FullID, job.Summary())
+				}
+			}
+			err := client.DeleteCollection(ctx, collection.FullID)
+			if err != nil {
+				return "", fmt.Errorf("failed to delete collection: %w", err)
+			}
+			message := fmt.Sprintf("Collection %d deleted successfully", collection.FullID)
+			if job != nil {
+				message += "\n\nContents moved out first:\n" + job.Summary()
+			}
+			return message, nil
+		})
+		if out != nil {
+			out.Job = job
+		}
+		return out, text, err
 	})
 
-	mcp.AddTool(server, &mcp.Tool{
+	addTool(r, policy.Collections, &mcp.Tool{
 		Name:        "merge-collections",
-		Description: "Merge multiple collections into one",
-	}, func(ctx context.Context, req *mcp.CallToolRequest, input MergeCollectionsInput) (*mcp.CallToolResult, TextOutput, error) {
-		err := client.MergeCollections(input.IDs, input.TargetID)
+		Description: "Merge multiple collections into one" + confirmNote,
+		Annotations: &mcp.ToolAnnotations{
+			Title:           "Merge Collections",
+			ReadOnlyHint:    false,
+			DestructiveHint: boolPtr(true),
+			IdempotentHint:  true,
+			OpenWorldHint:   boolPtr(false),
+		},
+	}, func(ctx context.Context, req *mcp.CallToolRequest, client *api.Client, input MergeCollectionsInput) (*ChangeOutput, string, error) {
+		if len(input.IDs) == 0 {
+			return nil, "", errors.New("give the collections to merge")
+		}
+		resolver := collections.NewResolver(client)
+		ids, err := resolver.ResolveAll(ctx, input.IDs)
 		if err != nil {
-			return nil, TextOutput{}, fmt.Errorf("failed to merge collections: %w", err)
+			return nil, "", err
 		}
-		return nil, TextOutput{Text: fmt.Sprintf("Merged %d collections into collection %d", len(input.IDs), input.TargetID)}, nil
+		target, err := resolver.Resolve(ctx, input.TargetID)
+		if err != nil {
+			return nil, "", err
+		}
+		index, err := resolver.Index(ctx)
+		if err != nil {
+			return nil, "", err
+		}
+		return r.destructive(ctx, req, input, input.ConfirmToken, func() (*impact, error) {
+			return mergeCollectionsImpact(index, ids, target)
+		}, func() (string, error) {
+			err := client.MergeCollections(ctx, ids, target)
+			if err != nil {
+				return "", fmt.Errorf("failed to merge collections: %w", err)
+			}
+			return fmt.Sprintf("Merged %d collections into collection %d", len(ids), target), nil
+		})
 	})
 
 	// --- Tags ---
 
-	mcp.AddTool(server, &mcp.Tool{
+	addTool(r, policy.Tags, &mcp.Tool{
 		Name:        "rename-tag",
 		Description: "Rename a tag",
-	}, func(ctx context.Context, req *mcp.CallToolRequest, input RenameTagInput) (*mcp.CallToolResult, TextOutput, error) {
-		err := client.RenameTag(input.Collection, input.OldName, input.NewName)
+		Annotations: &mcp.ToolAnnotations{
+			Title:           "Rename Tag",
+			ReadOnlyHint:    false,
+			DestructiveHint: boolPtr(true),
+			IdempotentHint:  true,
+			OpenWorldHint:   boolPtr(false),
+		},
+	}, func(ctx context.Context, req *mcp.CallToolRequest, client *api.Client, input RenameTagInput) (*ChangeOutput, string, error) {
+		collection, err := collections.NewResolver(client).Resolve(ctx, input.Collection)
+		if err != nil {
+			return nil, "", err
+		}
+		err = client.RenameTag(ctx, collection, input.OldName, input.NewName)
 		if err != nil {
-			return nil, TextOutput{}, fmt.Errorf("failed to rename tag: %w", err)
+			return nil, "", fmt.Errorf("failed to rename tag: %w", err)
 		}
-		return nil, TextOutput{Text: fmt.Sprintf("Tag '%s' renamed to '%s'", input.OldName, input.NewName)}, nil
+		return changed(fmt.Sprintf("Tag '%s' renamed to '%s'", input.OldName, input.NewName))
 	})
 
-	mcp.AddTool(server, &mcp.Tool{
+	addTool(r, policy.Tags, &mcp.Tool{
 		Name:        "delete-tags",
-		Description: "Delete one or more tags",
-	}, func(ctx context.Context, req *mcp.CallToolRequest, input DeleteTagsInput) (*mcp.CallToolResult, TextOutput, error) {
-		err := client.DeleteTags(input.Collection, input.Tags)
+		Description: "Delete one or more tags" + confirmNote,
+		Annotations: &mcp.ToolAnnotations{
+			Title:           "Delete Tags",
+			ReadOnlyHint:    false,
+			DestructiveHint: boolPtr(true),
+			IdempotentHint:  true,
+			OpenWorldHint:   boolPtr(false),
+		},
+	}, func(ctx context.Context, req *mcp.CallToolRequest, client *api.Client, input DeleteTagsInput) (*ChangeOutput, string, error) {
+		collection, err := collections.NewResolver(client).Resolve(ctx, input.Collection)
 		if err != nil {
-			return nil, TextOutput{}, fmt.Errorf("failed to delete tags: %w", err)
+			return nil, "", err
 		}
-		return nil, TextOutput{Text: fmt.Sprintf("Deleted %d tags", len(input.Tags))}, nil
+		return r.destructive(ctx, req, input, input.ConfirmToken, func() (*impact, error) {
+			return deleteTagsImpact(ctx, client, collection, input.Tags)
+		}, func() (string, error) {
+			err := client.DeleteTags(ctx, collection, input.Tags)
+			if err != nil {
+				return "", fmt.Errorf("failed to delete tags: %w", err)
+			}
+			return fmt.Sprintf("Deleted %d tags", len(input.Tags)), nil
+		})
 	})
 
-	mcp.AddTool(server, &mcp.Tool{
+	addTool(r, policy.Tags, &mcp.Tool{
 		Name:        "merge-tags",
-		Description: "Merge multiple tags into one (first tag becomes the merged name)",
-	}, func(ctx context.Context, req *mcp.CallToolRequest, input MergeTagsInput) (*mcp.CallToolResult, TextOutput, error) {
+		Description: "Merge multiple tags into one (first tag becomes the merged name)" + confirmNote,
+		Annotations: &mcp.ToolAnnotations{
+			Title:           "Merge Tags",
+			ReadOnlyHint:    false,
+			DestructiveHint: boolPtr(true),
+			IdempotentHint:  true,
+			OpenWorldHint:   boolPtr(false),
+		},
+	}, func(ctx context.Context, req *mcp.CallToolRequest, client *api.Client, input MergeTagsInput) (*ChangeOutput, string, error) {
 		if len(input.Tags) < 2 {
-			return nil, TextOutput{}, fmt.Errorf("at least 2 tags required for merge")
+			return nil, "", fmt.Errorf("at least 2 tags required for merge")
 		}
-		err := client.MergeTags(input.Collection, input.Tags)
+		collection, err := collections.NewResolver(client).Resolve(ctx, input.Collection)
 		if err != nil {
-			return nil, TextOutput{}, fmt.Errorf("failed to merge tags: %w", err)
+			return nil, "", err
 		}
-		return nil, TextOutput{Text: fmt.Sprintf("Merged tags into '%s'", input.Tags[0])}, nil
+		return r.destructive(ctx, req, input, input.ConfirmToken, func() (*impact, error) {
+			return mergeTagsImpact(ctx, client, collection, input.Tags)
+		}, func() (string, error) {
+			err := client.MergeTags(ctx, collection, input.Tags)
+			if err != nil {
+				return "", fmt.Errorf("failed to merge tags: %w", err)
+			}
+			return fmt.Sprintf("Merged tags into '%s'", input.Tags[0]), nil
+		})
 	})
 
 	// --- Highlights ---
 
-	mcp.AddTool(server, &mcp.Tool{
+	addTool(r, policy.Highlights, &mcp.Tool{
 		Name:        "get-highlights",
 		Description: "Get highlights from a bookmark or all highlights",
-	}, func(ctx context.Context, req *mcp.CallToolRequest, input GetHighlightsInput) (*mcp.CallToolResult, TextOutput, error) {
-		highlights, err := client.GetHighlights(input.RaindropID)
+		Annotations: &mcp.ToolAnnotations{
+			Title:           "Get Highlights",
+			ReadOnlyHint:    true,
+			DestructiveHint: boolPtr(false),
+			IdempotentHint:  true,
+			OpenWorldHint:   boolPtr(false),
+		},
+	}, func(ctx context.Context, req *mcp.CallToolRequest, client *api.Client, input GetHighlightsInput) (*HighlightsOutput, string, error) {
+		highlights, err := client.GetHighlights(ctx, input.RaindropID)
+		if err != nil {
+			return nil, "", fmt.Errorf("failed to get highlights: %w", err)
+		}
+		text, err := render.Highlights(highlights.Items, r.renderOptions(input.Listing))
 		if err != nil {
-			return nil, TextOutput{}, fmt.Errorf("failed to get highlights: %w", err)
+			return nil, "", err
 		}
-		return nil, TextOutput{Text: formatHighlights(highlights.Items)}, nil
+		return &HighlightsOutput{Highlights: highlights.Items}, text, nil
 	})
 
-	mcp.AddTool(server, &mcp.Tool{
+	addTool(r, policy.Highlights, &mcp.Tool{
 		Name:        "create-highlight",
 		Description: "Create a new highlight in a bookmark",
-	}, func(ctx context.Context, req *mcp.CallToolRequest, input CreateHighlightInput) (*mcp.CallToolResult, TextOutput, error) {
-		highlight, err := client.CreateHighlight(input.RaindropID, input.Text, input.Note, input.Color)
+		Annotations: &mcp.ToolAnnotations{
+			Title:           "Create Highlight",
+			ReadOnlyHint:    false,
+			DestructiveHint: boolPtr(false),
+			IdempotentHint:  false,
+			OpenWorldHint:   boolPtr(false),
+		},
+	}, func(ctx context.Context, req *mcp.CallToolRequest, client *api.Client, input CreateHighlightInput) (*HighlightOutput, string, error) {
+		highlight, err := client.CreateHighlight(ctx, input.RaindropID, input.Text, input.Note, input.Color)
 		if err != nil {
-			return nil, TextOutput{}, fmt.Errorf("failed to create highlight: %w", err)
+			return nil, "", fmt.Errorf("failed to create highlight: %w", err)
 		}
-		return nil, TextOutput{Text: formatHighlight(highlight)}, nil
+		return &HighlightOutput{Highlight: *highlight}, formatHighlight(highlight), nil
 	})
 
-	mcp.AddTool(server, &mcp.Tool{
+	addTool(r, policy.Highlights, &mcp.Tool{
 		Name:        "delete-highlight",
 		Description: "Delete a highlight",
-	}, func(ctx context.Context, req *mcp.CallToolRequest, input DeleteHighlightInput) (*mcp.CallToolResult, TextOutput, error) {
-		err := client.DeleteHighlight(input.RaindropID, input.HighlightID)
+		Annotations: &mcp.ToolAnnotations{
+			Title:           "Delete Highlight",
+			ReadOnlyHint:    false,
+			DestructiveHint: boolPtr(true),
+			IdempotentHint:  true,
+			OpenWorldHint:   boolPtr(false),
+		},
+	}, func(ctx context.Context, req *mcp.CallToolRequest, client *api.Client, input DeleteHighlightInput) (*ChangeOutput, string, error) {
+		err := client.DeleteHighlight(ctx, input.RaindropID, input.HighlightID)
 		if err != nil {
-			return nil, TextOutput{}, fmt.Errorf("failed to delete highlight: %w", err)
+			return nil, "", fmt.Errorf("failed to delete highlight: %w", err)
 		}
-		return nil, TextOutput{Text: fmt.Sprintf("Highlight deleted from bookmark %d", input.RaindropID)}, nil
+		return changed(fmt.Sprintf("Highlight deleted from bookmark %d", input.RaindropID))
 	})
 
 	// --- Filters ---
 
-	mcp.AddTool(server, &mcp.Tool{
+	addTool(r, policy.Collections, &mcp.Tool{
 		Name:        "get-filters",
 		Description: "Get available filters for a collection",
-	}, func(ctx context.Context, req *mcp.CallToolRequest, input GetFiltersInput) (*mcp.CallToolResult, TextOutput, error) {
-		filters, err := client.GetFilters(input.Collection)
+		Annotations: &mcp.ToolAnnotations{
+			Title:           "Get Collection Filters",
+			ReadOnlyHint:    true,
+			DestructiveHint: boolPtr(false),
+			IdempotentHint:  true,
+			OpenWorldHint:   boolPtr(false),
+		},
+	}, func(ctx context.Context, req *mcp.CallToolRequest, client *api.Client, input GetFiltersInput) (*FiltersOutput, string, error) {
+		collection, err := collections.NewResolver(client).Resolve(ctx, input.Collection)
+		if err != nil {
+			return nil, "", err
+		}
+		filters, err := client.GetFilters(ctx, collection)
 		if err != nil {
-			return nil, TextOutput{}, fmt.Errorf("failed to get filters: %w", err)
+			return nil, "", fmt.Errorf("failed to get filters: %w", err)
 		}
-		return nil, TextOutput{Text: formatFilters(filters)}, nil
+		out := &FiltersOutput{
+			Broken:     filters.Broken.Count,
+			Duplicates: filters.Duplicates.Count,
+			Tags:       filters.Tags,
+			Types:      filters.Types,
+		}
+		return out, formatFilters(filters), nil
 	})
 
 	// --- User ---
 
-	mcp.AddTool(server, &mcp.Tool{
+	addTool(r, policy.Account, &mcp.Tool{
 		Name:        "get-user",
 		Description: "Get current user information",
-	}, func(ctx context.Context, req *mcp.CallToolRequest, input struct{}) (*mcp.CallToolResult, TextOutput, error) {
-		user, err := client.GetUser()
+		Annotations: &mcp.ToolAnnotations{
+			Title:           "Get User",
+			ReadOnlyHint:    true,
+			DestructiveHint: boolPtr(false),
+			IdempotentHint:  true,
+			OpenWorldHint:   boolPtr(false),
+		},
+	}, func(ctx context.Context, req *mcp.CallToolRequest, client *api.Client, input struct{}) (*UserOutput, string, error) {
+		user, err := client.GetUser(ctx)
 		if err != nil {
-			return nil, TextOutput{}, fmt.Errorf("failed to get user: %w", err)
+			return nil, "", fmt.Errorf("failed to get user: %w", err)
 		}
-		return nil, TextOutput{Text: formatUser(user)}, nil
+		return &UserOutput{User: *user}, formatUser(user), nil
 	})
 
 	// --- Suggestions ---
 
-	mcp.AddTool(server, &mcp.Tool{
+	addTool(r, policy.Tags, &mcp.Tool{
 		Name:        "suggest-tags",
 		Description: "Get tag suggestions for a URL",
-	}, func(ctx context.Context, req *mcp.CallToolRequest, input SuggestTagsInput) (*mcp.CallToolResult, TextOutput, error) {
-		tags, err := client.SuggestTags(input.URL)
+		Annotations: &mcp.ToolAnnotations{
+			Title:           "Suggest Tags",
+			ReadOnlyHint:    true,
+			DestructiveHint: boolPtr(false),
+			IdempotentHint:  true,
+			OpenWorldHint:   boolPtr(true),
+		},
+	}, func(ctx context.Context, req *mcp.CallToolRequest, client *api.Client, input SuggestTagsInput) (*SuggestedTagsOutput, string, error) {
+		tags, err := client.SuggestTags(ctx, input.URL)
 		if err != nil {
-			return nil, TextOutput{}, fmt.Errorf("failed to get suggestions: %w", err)
+			return nil, "", fmt.Errorf("failed to get suggestions: %w", err)
 		}
+		out := &SuggestedTagsOutput{Tags: tags}
 		if len(tags) == 0 {
-			return nil, TextOutput{Text: "No tag suggestions available for this URL"}, nil
+			return out, "No tag suggestions available for this URL", nil
 		}
-		return nil, TextOutput{Text: fmt.Sprintf("Suggested tags: %s", strings.Join(tags, ", "))}, nil
+		return out, fmt.Sprintf("Suggested tags: %s", strings.Join(tags, ", ")), nil
 	})
 }
 
 // Input types for extended tools
 
 type CreateCollectionInput struct {
-	Title  string `json:"title" jsonschema:"Collection title"`
-	Parent int    `json:"parent,omitempty" jsonschema:"Parent collection ID (0 for root)"`
-	Public bool   `json:"public,omitempty" jsonschema:"Make collection public"`
+	Title  string          `json:"title" jsonschema:"Collection title"`
+	Parent collections.Ref `json:"parent,omitempty" jsonschema:"Parent collection ID, title or path (default root)"`
+	Public bool            `json:"public,omitempty" jsonschema:"Make collection public"`
+	Appearance
+}
+
+// Collection views in the Raindrop apps
+var collectionViews = []string{"list", "simple", "grid", "masonry"}
+
+// colorPattern matches hex colors such as #1e90ff
+var colorPattern = regexp.MustCompile(`^#([0-9a-fA-F]{3}|[0-9a-fA-F]{6})$`)
+
+// Appearance is how a collection looks in the Raindrop apps
+type Appearance struct {
+	View     string  `json:"view,omitempty" jsonschema:"How bookmarks are shown: list, simple, grid or masonry"`
+	Color    string  `json:"color,omitempty" jsonschema:"Collection color as a hex code such as #1e90ff"`
+	Cover    *string `json:"cover,omitempty" jsonschema:"Cover icon URL, e.g. from search-covers; an empty string removes the cover"`
+	Sort     *int    `json:"sort,omitempty" jsonschema:"Position among collections with the same parent; higher numbers come first"`
+	Expanded *bool   `json:"expanded,omitempty" jsonschema:"Whether the subcollections are shown expanded in the sidebar"`
+}
+
+func (a Appearance) validate() error {
+	if a.View != "" && !slices.Contains(collectionViews, a.View) {
+		return fmt.Errorf("unknown view %q; valid views are %s", a.View, strings.Join(collectionViews, ", "))
+	}
+	if a.Color != "" && !colorPattern.MatchString(a.Color) {
+		return fmt.Errorf("color %q is not a hex color such as #1e90ff", a.Color)
+	}
+	if a.Cover != nil && *a.Cover != "" {
+		if u, err := url.Parse(*a.Cover); err != nil || (u.Scheme != "http" && u.Scheme != "https") {
+			return fmt.Errorf("cover %q is not an http(s) URL", *a.Cover)
+		}
+	}
+	return nil
+}
+
+type SearchCoversInput struct {
+	Query string `json:"query" jsonschema:"What the icon should show, such as book or code"`
 }
 
 type GetCollectionInput struct {
-	ID int `json:"id" jsonschema:"Collection ID"`
+	ID collections.Ref `json:"id" jsonschema:"Collection ID, title or path"`
 }
 
 type UpdateCollectionInput struct {
-	ID     int    `json:"id" jsonschema:"Collection ID"`
-	Title  string `json:"title,omitempty" jsonschema:"New title"`
-	Parent int    `json:"parent,omitempty" jsonschema:"New parent collection ID"`
-	Public *bool  `json:"public,omitempty" jsonschema:"Make public or private"`
+	ID     collections.Ref `json:"id" jsonschema:"Collection ID, title or path"`
+	Title  string          `json:"title,omitempty" jsonschema:"New title"`
+	Parent collections.Ref `json:"parent,omitempty" jsonschema:"New parent collection ID, title or path; 0 moves it to the top level. Moving it into its own subcollections is refused"`
+	Public *bool           `json:"public,omitempty" jsonschema:"Make public or private"`
+	Appearance
 }
 
 type DeleteCollectionInput struct {
-	ID int `json:"id" jsonschema:"Collection ID to delete"`
+	ID           collections.Ref `json:"id" jsonschema:"Collection to delete: ID, title or path"`
+	Mode         string          `json:"mode,omitempty" jsonschema:"What to do if the collection has bookmarks or subcollections: abort (default) lists them and deletes nothing, move moves them to move_to first, recursive deletes them with it"`
+	MoveTo       collections.Ref `json:"move_to,omitempty" jsonschema:"With mode move: collection ID, title, path or unsorted to receive the bookmarks and subcollections"`
+	ConfirmToken string          `json:"confirm_token,omitempty" jsonschema:"Token from a previous dry-run call, when the client cannot confirm interactively"`
 }
 
+// What delete-collection does with a collection that is not empty
+const (
+	deleteAbort     = "abort"
+	deleteMove      = "move"
+	deleteRecursive = "recursive"
+)
+
+var deleteModes = []string{deleteAbort, deleteMove, deleteRecursive}
+
 type MergeCollectionsInput struct {
-	IDs      []int `json:"ids" jsonschema:"Collection IDs to merge"`
-	TargetID int   `json:"target_id" jsonschema:"Target collection ID"`
+	IDs          []collections.Ref `json:"ids" jsonschema:"Collections to merge: IDs, titles or paths"`
+	TargetID     collections.Ref   `json:"target_id" jsonschema:"Target collection ID, title or path"`
+	ConfirmToken string            `json:"confirm_token,omitempty" jsonschema:"Token from a previous dry-run call, when the client cannot confirm interactively"`
 }
 
 type RenameTagInput struct {
-	Collection int    `json:"collection,omitempty" jsonschema:"Collection ID (0 for all)"`
-	OldName    string `json:"old_name" jsonschema:"Current tag name"`
-	NewName    string `json:"new_name" jsonschema:"New tag name"`
+	Collection collections.Ref `json:"collection,omitempty" jsonschema:"Collection ID, title or path (default all)"`
+	OldName    string          `json:"old_name" jsonschema:"Current tag name"`
+	NewName    string          `json:"new_name" jsonschema:"New tag name"`
 }
 
 type DeleteTagsInput struct {
-	Collection int      `json:"collection,omitempty" jsonschema:"Collection ID (0 for all)"`
-	Tags       []string `json:"tags" jsonschema:"Tag names to delete"`
+	Collection   collections.Ref `json:"collection,omitempty" jsonschema:"Collection ID, title or path (default all)"`
+	Tags         []string        `json:"tags" jsonschema:"Tag names to delete"`
+	ConfirmToken string          `json:"confirm_token,omitempty" jsonschema:"Token from a previous dry-run call, when the client cannot confirm interactively"`
 }
 
 type MergeTagsInput struct {
-	Collection int      `json:"collection,omitempty" jsonschema:"Collection ID (0 for all)"`
-	Tags       []string `json:"tags" jsonschema:"Tags to merge (first becomes target)"`
+	Collection   collections.Ref `json:"collection,omitempty" jsonschema:"Collection ID, title or path (default all)"`
+	Tags         []string        `json:"tags" jsonschema:"Tags to merge (first becomes target)"`
+	ConfirmToken string          `json:"confirm_token,omitempty" jsonschema:"Token from a previous dry-run call, when the client cannot confirm interactively"`
 }
 
 type GetHighlightsInput struct {
 	RaindropID int `json:"raindrop_id,omitempty" jsonschema:"Bookmark ID (0 for all highlights)"`
+	Listing
 }
 
 type CreateHighlightInput struct {
@@ -255,7 +633,7 @@ type DeleteHighlightInput struct {
 }
 
 type GetFiltersInput struct {
-	Collection int `json:"collection" jsonschema:"Collection ID"`
+	Collection collections.Ref `json:"collection" jsonschema:"Collection ID, title, path or all"`
 }
 
 type SuggestTagsInput struct {
@@ -274,6 +652,21 @@ func formatCollection(c *types.Collection) string {
 	if c.Parent != nil && c.Parent.ID > 0 {
 		sb.WriteString(fmt.Sprintf("Parent: %d\n", c.Parent.ID))
 	}
+	if c.View != "" {
+		sb.WriteString(fmt.Sprintf("View: %s\n", c.View))
+	}
+	if c.Color != "" {
+		sb.WriteString(fmt.Sprintf("Color: %s\n", c.Color))
+	}
+	if len(c.Cover) > 0 {
+		sb.WriteString(fmt.Sprintf("Cover: %s\n", c.Cover[0]))
+	}
+	if c.Sort != 0 {
+		sb.WriteString(fmt.Sprintf("Sort: %d\n", c.Sort))
+	}
+	if c.Expanded {
+		sb.WriteString("Expanded: Yes\n")
+	}
 	return sb.String()
 }
 
@@ -290,25 +683,6 @@ func formatHighlight(h *types.Highlight) string {
 	return sb.String()
 }
 
-func formatHighlights(highlights []types.Highlight) string {
-	if len(highlights) == 0 {
-		return "No highlights found."
-	}
-
-	var sb strings.Builder
-	sb.WriteString(fmt.Sprintf("Found %d highlights:\n\n", len(highlights)))
-
-	for i, h := range highlights {
-		sb.WriteString(fmt.Sprintf("%d. **%s**\n", i+1, truncate(h.Text, 100)))
-		if h.Note != "" {
-			sb.WriteString(fmt.Sprintf("   Note: %s\n", h.Note))
-		}
-		sb.WriteString(fmt.Sprintf("   ID: %s\n\n", h.ID))
-	}
-
-	return sb.String()
-}
-
 func formatFilters(f *types.FiltersResponse) string {
 	var sb strings.Builder
 	sb.WriteString("**Filters:**\n\n")
@@ -353,10 +727,3 @@ func formatUser(u *types.User) string {
 	sb.WriteString(fmt.Sprintf("Registered: %s\n", u.Registered))
 	return sb.String()
 }
-
-func truncate(s string, maxLen int) string {
-	if len(s) <= maxLen {
-		return s
-	}
-	return s[:maxLen-3] + "..."
-}
@@ -0,0 +1,169 @@
+package tools
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"raindrop-mcp/api"
+	"raindrop-mcp/collections"
+	"raindrop-mcp/render"
+	"raindrop-mcp/types"
+)
+
+// deleteCollectionImpact lists the bookmarks and subcollections a delete
+// affects, and where they go in the given mode
+func deleteCollectionImpact(index *collections.Index, c *contents, mode string, target int) *impact {
+	node := c.node
+	id := node.Collection.FullID
+	if c.empty() {
+		return &impact{Summary: fmt.Sprintf("Delete collection %s (ID: %d)", pathLabel(index, id), id), Details: []string{"it has no bookmarks or subcollections"}}
+	}
+
+	imp := &impact{}
+	if mode == deleteMove {
+		where := targetLabel(index, target)
+		imp.Summary = fmt.Sprintf("Move the contents of %s (ID: %d) to %s, then delete it", pathLabel(index, id), id, where)
+		if own := c.bookmarks[id]; len(own) > 0 {
+			imp.Details = append(imp.Details, fmt.Sprintf("%s to %s: %s", bookmarksMove(len(own)), where, bookmarkTitles(own)))
+		}
+		under := "under " + where
+		if target <= 0 {
+			under = "to the top level"
+		}
+		for _, child := range node.Children {
+			imp.Details = append(imp.Details, fmt.Sprintf("subcollection %s (ID: %d) moves %s with %s",
+				pathLabel(index, child.Collection.FullID), child.Collection.FullID, under, render.CountNoun(child.SubtreeCount, "bookmark")))
+		}
+		return imp
+	}
+
+	nested := -1 // the collection itself
+	collections.Walk([]*collections.Node{node}, func(*collections.Node) { nested++ })
+	imp.Summary = fmt.Sprintf("Delete collection %s (ID: %d) with %s and %s",
+		pathLabel(index, id), id, render.CountNoun(nested, "subcollection"), render.CountNoun(node.SubtreeCount, "bookmark"))
+	collections.Walk([]*collections.Node{node}, func(n *collections.Node) {
+		detail := fmt.Sprintf("%s (ID: %d)", pathLabel(index, n.Collection.FullID), n.Collection.FullID)
+		if n != node {
+			detail = "subcollection " + detail + " is removed"
+		}
+		if bookmarks := c.bookmarks[n.Collection.FullID]; len(bookmarks) > 0 {
+			detail += fmt.Sprintf(": %s to Trash: %s", bookmarksMove(len(bookmarks)), bookmarkTitles(bookmarks))
+		} else {
+			detail += ": no bookmarks"
+		}
+		imp.Details = append(imp.Details, detail)
+	})
+	return imp
+}
+
+func bookmarksMove(n int) string {
+	if n == 1 {
+		return "1 bookmark moves"
+	}
+	return fmt.Sprintf("%d bookmarks move", n)
+}
+
+// targetLabel names the collection contents are moved to
+func targetLabel(index *collections.Index, id int) string {
+	if id == collections.Unsorted {
+		return "Unsorted"
+	}
+	return pathLabel(index, id)
+}
+
+// mergeCollectionsImpact lists the collections merged away and the bookmarks
+// moved, from the collection index alone
+func mergeCollectionsImpact(index *collections.Index, ids []int, targetID int) (*impact, error) {
+	if _, ok := index.Get(targetID); !ok {
+		return nil, fmt.Errorf("collection %d not found", targetID)
+	}
+
+	tree := index.Tree()
+	imp := &impact{}
+	merged, moved := 0, 0
+	seen := map[int]bool{targetID: true}
+	for _, id := range ids {
+		if seen[id] {
+			continue
+		}
+		seen[id] = true
+		node, ok := collections.Subtree(tree, id)
+		if !ok {
+			return nil, fmt.Errorf("collection %d not found", id)
+		}
+		count := node.Collection.Count
+		merged++
+		moved += count
+		imp.Details = append(imp.Details, fmt.Sprintf("%s (ID: %d): %s, then the collection is removed", pathLabel(index, id), id, bookmarksMove(count)))
+
+		var nested []types.Collection
+		collections.Walk(node.Children, func(n *collections.Node) { nested = append(nested, n.Collection) })
+		if len(nested) > 0 {
+			imp.Details = append(imp.Details, fmt.Sprintf("nested collections of %s affected: %s", pathLabel(index, id), collectionTitles(nested)))
+		}
+	}
+	if merged == 0 {
+		return nil, errors.New("nothing to merge: give collections other than the target")
+	}
+	imp.Summary = fmt.Sprintf("Merge %s (%s) into %s (ID: %d)",
+		render.CountNoun(merged, "collection"), render.CountNoun(moved, "bookmark"), pathLabel(index, targetID), targetID)
+	return imp, nil
+}
+
+// deleteTagsImpact lists how many bookmarks lose each tag
+func deleteTagsImpact(ctx context.Context, client *api.Client, collectionID int, tags []string) (*impact, error) {
+	counts, err := tagCounts(ctx, client, collectionID)
+	if err != nil {
+		return nil, err
+	}
+
+	imp := &impact{Summary: fmt.Sprintf("Delete %d tags %s", len(tags), scopeLabel(collectionID))}
+	for _, tag := range tags {
+		imp.Details = append(imp.Details, fmt.Sprintf("%q is removed from %d bookmarks", tag, counts[strings.ToLower(tag)]))
+	}
+	return imp, nil
+}
+
+// mergeTagsImpact lists how many bookmarks are retagged
+func mergeTagsImpact(ctx context.Context, client *api.Client, collectionID int, tags []string) (*impact, error) {
+	counts, err := tagCounts(ctx, client, collectionID)
+	if err != nil {
+		return nil, err
+	}
+
+	imp := &impact{Summary: fmt.Sprintf("Merge %d tags into %q %s", len(tags)-1, tags[0], scopeLabel(collectionID))}
+	for _, tag := range tags[1:] {
+		imp.Details = append(imp.Details, fmt.Sprintf("%q on %d bookmarks becomes %q and is removed", tag, counts[strings.ToLower(tag)], tags[0]))
+	}
+	return imp, nil
+}
+
+// tagCounts maps lowercased tag names to bookmark counts
+func tagCounts(ctx context.Context, client *api.Client, collectionID int) (map[string]int, error) {
+	tags, err := client.GetTags(ctx, collectionID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get tags: %w", err)
+	}
+	counts := make(map[string]int, len(tags.Items))
+	for _, t := range tags.Items {
+		counts[strings.ToLower(t.ID)] = t.Count
+	}
+	return counts, nil
+}
+
+func collectionTitles(collections []types.Collection) string {
+	titles := make([]string, len(collections))
+	for i, c := range collections {
+		titles[i] = fmt.Sprintf("%q (ID: %d)", c.Title, c.FullID)
+	}
+	return strings.Join(titles, ", ")
+}
+
+func scopeLabel(collectionID int) string {
+	if collectionID > 0 {
+		return fmt.Sprintf("in collection %d", collectionID)
+	}
+	return "across all collections"
+}
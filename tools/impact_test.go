@@ -0,0 +1,69 @@
+package tools
+
+import (
+	"slices"
+	"strings"
+	"testing"
+
+	"raindrop-mcp/collections"
+	"raindrop-mcp/types"
+)
+
+func TestMergeCollectionsImpact(t *testing.T) {
+	child := func(id int, title string, count, parent int) types.Collection {
+		return types.Collection{FullID: id, Title: title, Count: count, Parent: &types.Parent{ID: parent}}
+	}
+	index := collections.NewIndex([]types.Collection{
+		{FullID: 5, Title: "Work", Count: 3},
+		{FullID: 6, Title: "Reading", Count: 1},
+		child(7, "Go", 2, 5),
+		child(8, "Deep", 1, 7),
+	})
+
+	tests := []struct {
+		name        string
+		ids         []int
+		target      int
+		wantSummary string
+		wantDetails []string
+		wantErr     string
+	}{
+		{
+			name: "with nested collections", ids: []int{5, 6, 5}, target: 6,
+			wantSummary: `Merge 1 collection (3 bookmarks) into "/Reading" (ID: 6)`,
+			wantDetails: []string{
+				`"/Work" (ID: 5): 3 bookmarks move, then the collection is removed`,
+				`nested collections of "/Work" affected: "Go" (ID: 7), "Deep" (ID: 8)`,
+			},
+		},
+		{
+			name: "several sources", ids: []int{6, 8}, target: 5,
+			wantSummary: `Merge 2 collections (2 bookmarks) into "/Work" (ID: 5)`,
+			wantDetails: []string{
+				`"/Reading" (ID: 6): 1 bookmark moves, then the collection is removed`,
+				`"/Work/Go/Deep" (ID: 8): 1 bookmark moves, then the collection is removed`,
+			},
+		},
+		{name: "only the target", ids: []int{6}, target: 6, wantErr: "nothing to merge"},
+		{name: "no ids", target: 6, wantErr: "nothing to merge"},
+		{name: "unknown source", ids: []int{99}, target: 6, wantErr: "collection 99 not found"},
+		{name: "unknown target", ids: []int{5}, target: 99, wantErr: "collection 99 not found"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			imp, err := mergeCollectionsImpact(index, tt.ids, tt.target)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("mergeCollectionsImpact(%v, %d) error = %v, want one containing %q", tt.ids, tt.target, err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if imp.Summary != tt.wantSummary || !slices.Equal(imp.Details, tt.wantDetails) {
+				t.Errorf("mergeCollectionsImpact(%v, %d) = %q, %q; want %q, %q", tt.ids, tt.target, imp.Summary, imp.Details, tt.wantSummary, tt.wantDetails)
+			}
+		})
+	}
+}
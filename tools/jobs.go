@@ -0,0 +1,131 @@
+package tools
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"strings"
+	"time"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+)
+
+// progressInterval limits how often intermediate progress is sent
+const progressInterval = 250 * time.Millisecond
+
+// progress reports MCP progress notifications for a tool call. It does
+// nothing unless the client sent a progress token with the request.
+type progress struct {
+	ctx   context.Context
+	ss    *mcp.ServerSession
+	token any
+	last  time.Time
+}
+
+func newProgress(ctx context.Context, req *mcp.CallToolRequest) *progress {
+	return &progress{ctx: ctx, ss: req.Session, token: req.Params.GetProgressToken()}
+}
+
+// report sends done out of total (0 when unknown). Updates closer together
+// than progressInterval are dropped unless final is set.
+func (p *progress) report(done, total int, message string, final bool) {
+	if p.token == nil || p.ss == nil {
+		return
+	}
+	if !final && time.Since(p.last) < progressInterval {
+		return
+	}
+	p.last = time.Now()
+
+	params := &mcp.ProgressNotificationParams{
+		ProgressToken: p.token,
+		Progress:      float64(done),
+		Message:       message,
+	}
+	if total > 0 {
+		params.Total = float64(total)
+	}
+	if err := p.ss.NotifyProgress(p.ctx, params); err != nil {
+		slog.DebugContext(p.ctx, "Failed to send progress", "error", err)
+	}
+}
+
+// jobFailure records an item a job could not process
+type jobFailure struct {
+	Item  string `json:"item"`
+	Error string `json:"error"`
+}
+
+// jobResult summarises a job, including one stopped before it finished
+type jobResult struct {
+	Done      int          `json:"done"`
+	Failed    int          `json:"failed"`
+	Remaining int          `json:"remaining"`
+	Cancelled bool         `json:"cancelled"`
+	Failures  []jobFailure `json:"failures,omitempty"`
+}
+
+// fail records a failed item
+func (r *jobResult) fail(item string, err error) {
+	r.Failed++
+	r.Failures = append(r.Failures, jobFailure{Item: item, Error: err.Error()})
+}
+
+// state is the final progress message
+func (r *jobResult) state() string {
+	if r.Cancelled {
+		return "cancelled"
+	}
+	return "finished"
+}
+
+// Summary describes the outcome in one line plus any failures
+func (r *jobResult) Summary() string {
+	var sb strings.Builder
+	switch {
+	case r.Cancelled:
+		sb.WriteString("Cancelled before finishing: ")
+	case r.Remaining > 0:
+		sb.WriteString("Stopped before finishing: ")
+	default:
+		sb.WriteString("Finished: ")
+	}
+	sb.WriteString(fmt.Sprintf("%d done, %d failed, %d remaining\n", r.Done, r.Failed, r.Remaining))
+	for _, f := range r.Failures {
+		sb.WriteString(fmt.Sprintf("- %s: %s\n", f.Item, f.Error))
+	}
+	return sb.String()
+}
+
+// runJob calls step for each item in order, reporting progress as it goes.
+// A failed item is recorded and the job moves on; cancelling the request
+// stops it and leaves the rest as remaining.
+func runJob[T any](ctx context.Context, req *mcp.CallToolRequest, items []T, describe func(T) string, step func(context.Context, T) error) *jobResult {
+	p := newProgress(ctx, req)
+	result := &jobResult{Remaining: len(items)}
+
+	for _, item := range items {
+		if ctx.Err() != nil {
+			result.Cancelled = true
+			break
+		}
+		if err := step(ctx, item); err != nil {
+			if ctx.Err() != nil {
+				// Interrupted by cancellation, not a real failure
+				result.Cancelled = true
+				break
+			}
+			result.fail(describe(item), err)
+		} else {
+			result.Done++
+		}
+		result.Remaining--
+		p.report(result.Done+result.Failed, len(items), describe(item), false)
+	}
+
+	p.report(result.Done+result.Failed, len(items), result.state(), true)
+	if result.Cancelled {
+		slog.InfoContext(ctx, "Job cancelled", "tool", req.Params.Name, "done", result.Done, "remaining", result.Remaining)
+	}
+	return result
+}
@@ -0,0 +1,253 @@
+package tools
+
+import (
+	"fmt"
+	"reflect"
+
+	"raindrop-mcp/collections"
+	"raindrop-mcp/types"
+
+	"github.com/google/jsonschema-go/jsonschema"
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+)
+
+// Tool outputs are returned as structured content, with an output schema
+// derived from the type, next to a human-readable text block
+
+// Acted is embedded in every output to report the account a call used
+type Acted struct {
+	Account string `json:"account" jsonschema:"Account the call acted on"`
+}
+
+func (a *Acted) setAccount(label string) {
+	a.Account = label
+}
+
+// output is implemented by pointers to the output types
+type output interface {
+	setAccount(label string)
+}
+
+// BookmarkOutput is a single bookmark
+type BookmarkOutput struct {
+	Acted
+	Bookmark types.Raindrop `json:"bookmark"`
+}
+
+// UpdateBookmarkOutput is an updated bookmark and what changed
+type UpdateBookmarkOutput struct {
+	Acted
+	Bookmark types.Raindrop `json:"bookmark"`
+	Changes  []FieldChange  `json:"changes" jsonschema:"Fields whose value changed"`
+}
+
+// FieldChange is a field's value before and after an update
+type FieldChange struct {
+	Field  string `json:"field"`
+	Before any    `json:"before"`
+	After  any    `json:"after"`
+}
+
+// BookmarksOutput is one page of bookmarks
+type BookmarksOutput struct {
+	Acted
+	Count     int              `json:"count" jsonschema:"Number of matching bookmarks across all pages"`
+	Bookmarks []types.Raindrop `json:"bookmarks"`
+}
+
+// BatchBookmarksOutput is the bookmarks fetched by ID, the IDs that were not
+// found and how far the fetch got
+type BatchBookmarksOutput struct {
+	Acted
+	Bookmarks []types.Raindrop `json:"bookmarks" jsonschema:"Bookmarks found, in the order requested"`
+	Missing   []int            `json:"missing" jsonschema:"IDs that do not exist"`
+	Job       *jobResult       `json:"job" jsonschema:"Progress of the fetch, including IDs that could not be fetched and whether it was cancelled"`
+}
+
+// ExportOutput is the bookmarks an export fetched and how far it got
+type ExportOutput struct {
+	Acted
+	Bookmarks []types.Raindrop `json:"bookmarks"`
+	Job       *jobResult       `json:"job" jsonschema:"Progress of the export, including whether it was cancelled"`
+}
+
+// TagEditOutput is the outcome of adding or removing tags on many bookmarks
+type TagEditOutput struct {
+	Acted
+	Results []TagEditResult `json:"results" jsonschema:"One entry per bookmark processed, in order"`
+	Job     *jobResult      `json:"job"`
+}
+
+// TagEditResult is the outcome for one bookmark
+type TagEditResult struct {
+	ID      int      `json:"id"`
+	Title   string   `json:"title,omitempty"`
+	Before  []string `json:"before" jsonschema:"Tags before the edit"`
+	After   []string `json:"after" jsonschema:"Tags after the edit"`
+	Changed bool     `json:"changed"`
+	Error   string   `json:"error,omitempty"`
+}
+
+// CollectionOutput is a single collection
+type CollectionOutput struct {
+	Acted
+	Collection types.Collection `json:"collection"`
+}
+
+// CoversOutput is the cover icons found by a search
+type CoversOutput struct {
+	Acted
+	Covers []types.CoverGroup `json:"covers"`
+}
+
+// CollectionsOutput is a list of collections
+type CollectionsOutput struct {
+	Acted
+	Collections []types.Collection `json:"collections"`
+}
+
+// CollectionTreeOutput is a collection tree, parents before their children
+type CollectionTreeOutput struct {
+	Acted
+	Collections []TreeEntry `json:"collections"`
+}
+
+// TreeEntry is a collection in a tree
+type TreeEntry struct {
+	ID           int    `json:"id"`
+	Title        string `json:"title"`
+	Path         string `json:"path" jsonschema:"Titles from the root down, separated by /"`
+	Parent       int    `json:"parent" jsonschema:"Parent collection ID, 0 for root collections"`
+	Depth        int    `json:"depth" jsonschema:"0 for root collections"`
+	Count        int    `json:"count" jsonschema:"Bookmarks in the collection itself"`
+	SubtreeCount int    `json:"subtree_count" jsonschema:"Bookmarks in the collection and its subcollections"`
+}
+
+// TagsOutput is a list of tags with their bookmark counts
+type TagsOutput struct {
+	Acted
+	Tags []types.Tag `json:"tags"`
+}
+
+// SuggestedTagsOutput is a list of suggested tag names
+type SuggestedTagsOutput struct {
+	Acted
+	Tags []string `json:"tags"`
+}
+
+// HighlightOutput is a single highlight
+type HighlightOutput struct {
+	Acted
+	Highlight types.Highlight `json:"highlight"`
+}
+
+// HighlightsOutput is a list of highlights
+type HighlightsOutput struct {
+	Acted
+	Highlights []types.Highlight `json:"highlights"`
+}
+
+// FiltersOutput summarises the filters available in a collection
+type FiltersOutput struct {
+	Acted
+	Broken     int                  `json:"broken" jsonschema:"Number of broken links"`
+	Duplicates int                  `json:"duplicates" jsonschema:"Number of duplicates"`
+	Tags       []types.TagWithCount `json:"tags"`
+	Types      []types.TypeFilter   `json:"types"`
+}
+
+// UserOutput is the account's user
+type UserOutput struct {
+	Acted
+	User types.User `json:"user"`
+}
+
+// ChangeOutput reports the outcome of a tool that changes data
+type ChangeOutput struct {
+	Acted
+	Applied      bool       `json:"applied" jsonschema:"Whether the change was made"`
+	Message      string     `json:"message"`
+	ConfirmToken string     `json:"confirm_token,omitempty" jsonschema:"Pass back with the same input to apply a previewed change"`
+	Impact       *impact    `json:"impact,omitempty" jsonschema:"What the change affects"`
+	Job          *jobResult `json:"job,omitempty" jsonschema:"Items processed on the way, for changes made in steps"`
+}
+
+// PlanOutput is the plan of a collection reorganisation and, unless it was
+// a dry run, how carrying it out went
+type PlanOutput struct {
+	Acted
+	Applied     bool               `json:"applied" jsonschema:"Whether the plan was carried out"`
+	Plan        *impact            `json:"plan"`
+	Collections []types.Collection `json:"collections,omitempty" jsonschema:"Collections created"`
+	Job         *jobResult         `json:"job,omitempty" jsonschema:"Bookmarks copied or moved"`
+}
+
+// ProfileInfo is a configured account profile
+type ProfileInfo struct {
+	Name    string `json:"name"`
+	Active  bool   `json:"active" jsonschema:"Whether this session uses the profile"`
+	Default bool   `json:"default" jsonschema:"Whether new sessions start on the profile"`
+}
+
+// ProfilesOutput is the list of configured profiles
+type ProfilesOutput struct {
+	Acted
+	Profiles []ProfileInfo `json:"profiles"`
+}
+
+// ProfileOutput is the profile a session switched to
+type ProfileOutput struct {
+	Acted
+	Profile string `json:"profile"`
+}
+
+// changed returns the output of a change that was applied
+func changed(message string) (*ChangeOutput, string, error) {
+	return &ChangeOutput{Applied: true, Message: message}, message, nil
+}
+
+// setInputSchema publishes the schema of In as the tool's input schema,
+// letting collection references be numbers or strings. It reports false if
+// the schema is invalid.
+func setInputSchema[In any](r *Registry, tool *mcp.Tool) bool {
+	schema, err := jsonschema.ForType(reflect.TypeFor[In](), &jsonschema.ForOptions{
+		TypeSchemas: map[reflect.Type]*jsonschema.Schema{
+			reflect.TypeFor[collections.Ref](): collections.RefSchema(),
+		},
+	})
+	if err != nil {
+		r.invalid = append(r.invalid, fmt.Errorf("tool %s: input schema: %w", tool.Name, err))
+		return false
+	}
+	tool.InputSchema = schema
+	return true
+}
+
+// setOutputSchema publishes the schema of Out, a pointer to an output type,
+// as the tool's output schema. It reports false if the schema is invalid.
+func setOutputSchema[Out output](r *Registry, tool *mcp.Tool) bool {
+	schema, err := jsonschema.ForType(reflect.TypeFor[Out]().Elem(), &jsonschema.ForOptions{})
+	if err != nil {
+		r.invalid = append(r.invalid, fmt.Errorf("tool %s: output schema: %w", tool.Name, err))
+		return false
+	}
+	nullableArrays(schema)
+	tool.OutputSchema = schema
+	return true
+}
+
+// nullableArrays lets every array in s be null, since Go encodes nil slices
+// as null and Raindrop omits empty lists
+func nullableArrays(s *jsonschema.Schema) {
+	if s == nil {
+		return
+	}
+	if s.Type == "array" {
+		s.Type = ""
+		s.Types = []string{"null", "array"}
+	}
+	for _, p := range s.Properties {
+		nullableArrays(p)
+	}
+	nullableArrays(s.Items)
+}
@@ -0,0 +1,98 @@
+package tools
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"strings"
+
+	"raindrop-mcp/logging"
+	"raindrop-mcp/policy"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+)
+
+// RegisterProfileTools registers tools for listing and switching accounts
+func RegisterProfileTools(r *Registry) {
+	accounts := r.accounts
+
+	listProfiles := &mcp.Tool{
+		Name:        "list-profiles",
+		Description: "List the configured Raindrop accounts (profiles) and which one this session uses",
+		Annotations: &mcp.ToolAnnotations{
+			Title:           "List Profiles",
+			ReadOnlyHint:    true,
+			DestructiveHint: boolPtr(false),
+			IdempotentHint:  true,
+			OpenWorldHint:   boolPtr(false),
+		},
+	}
+	if r.add(listProfiles, policy.Account) && setOutputSchema[*ProfilesOutput](r, listProfiles) {
+		mcp.AddTool(r.server, listProfiles, func(ctx context.Context, req *mcp.CallToolRequest, input struct{}) (*mcp.CallToolResult, *ProfilesOutput, error) {
+			profiles, err := accounts.Profiles()
+			if err != nil {
+				return nil, nil, fmt.Errorf("failed to list profiles: %w", err)
+			}
+
+			active := accounts.ActiveProfile(req.Session)
+			account, err := accounts.Account(active)
+			if err != nil {
+				return nil, nil, err
+			}
+
+			out := &ProfilesOutput{}
+			for _, p := range profiles {
+				out.Profiles = append(out.Profiles, ProfileInfo{Name: p, Active: p == active, Default: p == accounts.DefaultProfile()})
+			}
+			return textResult(out, formatProfiles(profiles, active, accounts.DefaultProfile()), account.Label(ctx))
+		})
+	}
+
+	switchProfile := &mcp.Tool{
+		Name:        "switch-profile",
+		Description: "Switch the Raindrop account used by this session",
+		Annotations: &mcp.ToolAnnotations{
+			Title:           "Switch Profile",
+			ReadOnlyHint:    false,
+			DestructiveHint: boolPtr(false),
+			IdempotentHint:  true,
+			OpenWorldHint:   boolPtr(false),
+		},
+	}
+	if r.add(switchProfile, policy.Account) && setOutputSchema[*ProfileOutput](r, switchProfile) {
+		mcp.AddTool(r.server, switchProfile, func(ctx context.Context, req *mcp.CallToolRequest, input SwitchProfileInput) (*mcp.CallToolResult, *ProfileOutput, error) {
+			account, err := accounts.Switch(req.Session, input.Profile)
+			if err != nil {
+				return nil, nil, fmt.Errorf("failed to switch profile: %w", err)
+			}
+			slog.InfoContext(logging.WithSession(ctx, req.Session), "Switched profile", "profile", account.Profile)
+			return textResult(&ProfileOutput{Profile: account.Profile}, fmt.Sprintf("Now using account %s", account.Label(ctx)), account.Label(ctx))
+		})
+	}
+}
+
+type SwitchProfileInput struct {
+	Profile string `json:"profile" jsonschema:"Profile name (see list-profiles)"`
+}
+
+func formatProfiles(profiles []string, active, defaultProfile string) string {
+	var sb strings.Builder
+	sb.WriteString(fmt.Sprintf("Found %d profiles:\n\n", len(profiles)))
+
+	for _, p := range profiles {
+		var marks []string
+		if p == active {
+			marks = append(marks, "active")
+		}
+		if p == defaultProfile {
+			marks = append(marks, "default")
+		}
+		if len(marks) > 0 {
+			sb.WriteString(fmt.Sprintf("- **%s** (%s)\n", p, strings.Join(marks, ", ")))
+		} else {
+			sb.WriteString(fmt.Sprintf("- %s\n", p))
+		}
+	}
+
+	return sb.String()
+}
@@ -0,0 +1,616 @@
+package tools
+
+import (
+	"cmp"
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"net/url"
+	"slices"
+	"strings"
+
+	"raindrop-mcp/api"
+	"raindrop-mcp/collections"
+	"raindrop-mcp/policy"
+	"raindrop-mcp/render"
+	"raindrop-mcp/types"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+)
+
+const (
+	// maxPlanItems caps the bookmarks a plan names one by one
+	maxPlanItems = 20
+	// defaultSplitMinCount is the fewest bookmarks split-collection makes a
+	// subcollection for
+	defaultSplitMinCount = 2
+)
+
+// Ways split-collection can group bookmarks
+const (
+	splitByTag    = "tag"
+	splitByDomain = "domain"
+)
+
+// RegisterSubtreeTools registers tools that reorganise collections: moving
+// a branch, copying a collection and splitting one into subcollections.
+// Each shows its plan first when called with dry_run.
+func RegisterSubtreeTools(r *Registry) {
+	addTool(r, policy.Collections, &mcp.Tool{
+		Name:        "move-collection",
+		Description: "Move a collection with all of its subcollections under another collection, or to the top level. Refuses to move a collection into itself or one of its subcollections; with dry_run, lists the paths before and after without moving anything",
+		Annotations: &mcp.ToolAnnotations{
+			Title:           "Move Collection",
+			ReadOnlyHint:    false,
+			DestructiveHint: boolPtr(false),
+			IdempotentHint:  true,
+			OpenWorldHint:   boolPtr(false),
+		},
+	}, func(ctx context.Context, req *mcp.CallToolRequest, client *api.Client, input MoveCollectionInput) (*PlanOutput, string, error) {
+		resolver := collections.NewResolver(client)
+		ids, err := resolver.ResolveAll(ctx, []collections.Ref{input.ID, input.Parent})
+		if err != nil {
+			return nil, "", err
+		}
+		index, err := resolver.Index(ctx)
+		if err != nil {
+			return nil, "", err
+		}
+		id, parent := ids[0], ids[1]
+		plan, moves, err := movePlan(index, id, parent)
+		if err != nil {
+			return nil, "", err
+		}
+		if !moves {
+			return &PlanOutput{Plan: plan}, plan.String() + "\nNothing to do.", nil
+		}
+		return planned(plan, input.DryRun, func(out *PlanOutput) (string, error) {
+			if _, err := client.MoveCollection(ctx, id, parent); err != nil {
+				return "", fmt.Errorf("failed to move collection: %w", err)
+			}
+			return fmt.Sprintf("Collection %d moved successfully", id), nil
+		})
+	})
+
+	addTool(r, policy.Collections, &mcp.Tool{
+		Name:        "clone-collection",
+		Description: "Copy a collection: creates a new collection with the same visibility, view, color, cover, sort position and expanded state, and copies every bookmark in it, keeping titles, excerpts, notes, tags, covers and the important flag. Subcollections are not copied; with dry_run, lists what would be copied",
+		Annotations: &mcp.ToolAnnotations{
+			Title:           "Clone Collection",
+			ReadOnlyHint:    false,
+			DestructiveHint: boolPtr(false),
+			IdempotentHint:  false,
+			OpenWorldHint:   boolPtr(false),
+		},
+	}, func(ctx context.Context, req *mcp.CallToolRequest, client *api.Client, input CloneCollectionInput) (*PlanOutput, string, error) {
+		resolver := collections.NewResolver(client)
+		index, source, err := resolveUserCollection(ctx, resolver, input.ID)
+		if err != nil {
+			return nil, "", err
+		}
+		// Copies go next to the source unless another parent is given
+		parent := collections.ParentID(source)
+		if input.Parent != "" {
+			if parent, err = resolver.Resolve(ctx, input.Parent); err != nil {
+				return nil, "", err
+			}
+			if _, ok := index.Get(parent); parent != 0 && !ok {
+				return nil, "", fmt.Errorf("collection %d not found", parent)
+			}
+		}
+		title := strings.TrimSpace(input.Title)
+		if title == "" {
+			title = source.Title + " (copy)"
+		}
+
+		bookmarks, err := collectionBookmarks(ctx, client, source.FullID)
+		if err != nil {
+			return nil, "", err
+		}
+
+		where := "at the top level"
+		if parent != 0 {
+			where = "under " + pathLabel(index, parent)
+		}
+		plan := &impact{Summary: fmt.Sprintf("Copy %s from %s (ID: %d) into a new collection %q %s",
+			render.CountNoun(len(bookmarks), "bookmark"), pathLabel(index, source.FullID), source.FullID, title, where)}
+		if len(bookmarks) > 0 {
+			plan.Details = append(plan.Details, "bookmarks: "+bookmarkTitles(bookmarks))
+		}
+		if node, ok := collections.Subtree(index.Tree(), source.FullID); ok && len(node.Children) > 0 {
+			children := make([]types.Collection, len(node.Children))
+			for i, child := range node.Children {
+				children[i] = child.Collection
+			}
+			plan.Details = append(plan.Details, "subcollections, which are not copied: "+collectionTitles(children))
+		}
+
+		return planned(plan, input.DryRun, func(out *PlanOutput) (string, error) {
+			created, err := client.CreateCollectionFrom(ctx, cloneRequest(source, title, parent))
+			if err != nil {
+				return "", fmt.Errorf("failed to create collection: %w", err)
+			}
+			out.Collections = []types.Collection{*created}
+			out.Job = runJob(ctx, req, bookmarks, describeBookmark, func(ctx context.Context, b types.Raindrop) error {
+				_, err := client.CreateRaindropFrom(ctx, copyRequest(b, created.FullID))
+				return err
+			})
+			return fmt.Sprintf("Created collection %q (ID: %d)\n\n%s", created.Title, created.FullID, out.Job.Summary()), nil
+		})
+	})
+
+	addTool(r, policy.Collections, &mcp.Tool{
+		Name:        "split-collection",
+		Description: "Split a large collection into subcollections by tag or by domain. Bookmarks move into a subcollection named after their most common qualifying tag, or their domain; subcollections with those names are reused, and new ones get the collection's view. With dry_run, shows the groups without changing anything",
+		Annotations: &mcp.ToolAnnotations{
+			Title:           "Split Collection",
+			ReadOnlyHint:    false,
+			DestructiveHint: boolPtr(false),
+			IdempotentHint:  true,
+			OpenWorldHint:   boolPtr(false),
+		},
+	}, func(ctx context.Context, req *mcp.CallToolRequest, client *api.Client, input SplitCollectionInput) (*PlanOutput, string, error) {
+		if input.By != splitByTag && input.By != splitByDomain {
+			return nil, "", fmt.Errorf("by must be %q or %q", splitByTag, splitByDomain)
+		}
+		minCount := input.MinCount
+		if minCount <= 0 {
+			minCount = defaultSplitMinCount
+		}
+		index, source, err := resolveUserCollection(ctx, collections.NewResolver(client), input.ID)
+		if err != nil {
+			return nil, "", err
+		}
+		bookmarks, err := collectionBookmarks(ctx, client, source.FullID)
+		if err != nil {
+			return nil, "", err
+		}
+
+		groups, stay := splitGroups(bookmarks, input.By, minCount)
+		// Reuse subcollections that already have a group's name
+		if node, ok := collections.Subtree(index.Tree(), source.FullID); ok {
+			for i := range groups {
+				for _, child := range node.Children {
+					if strings.EqualFold(child.Collection.Title, groups[i].Name) {
+						groups[i].Collection = child.Collection.FullID
+						break
+					}
+				}
+			}
+		}
+
+		plan := splitPlan(index, source, input.By, minCount, groups, stay)
+		if len(groups) == 0 {
+			return &PlanOutput{Plan: plan}, plan.String() + "\nNothing to do.", nil
+		}
+		return planned(plan, input.DryRun, func(out *PlanOutput) (string, error) {
+			var moves []splitMove
+			for _, g := range groups {
+				if g.Collection == 0 {
+					created, err := client.CreateCollectionFrom(ctx, types.CreateCollectionRequest{
+						Title:  g.Name,
+						Public: source.Public,
+						Parent: &types.CollectionRef{ID: source.FullID},
+						View:   source.View,
+					})
+					if err != nil {
+						return "", fmt.Errorf("failed to create collection %q: %w", g.Name, err)
+					}
+					out.Collections = append(out.Collections, *created)
+					g.Collection = created.FullID
+				}
+				for _, b := range g.Bookmarks {
+					moves = append(moves, splitMove{Bookmark: b, Collection: g.Collection})
+				}
+			}
+			out.Job = runJob(ctx, req, moves, func(m splitMove) string {
+				return describeBookmark(m.Bookmark)
+			}, func(ctx context.Context, m splitMove) error {
+				_, err := client.UpdateRaindrop(ctx, m.Bookmark.ID, types.UpdateRaindropRequest{
+					Collection: &types.CollectionRef{ID: m.Collection},
+				})
+				return err
+			})
+			return fmt.Sprintf("Created %s\n\n%s", render.CountNoun(len(out.Collections), "subcollection"), out.Job.Summary()), nil
+		})
+	})
+}
+
+type MoveCollectionInput struct {
+	ID     collections.Ref `json:"id" jsonschema:"Collection to move: ID, title or path"`
+	Parent collections.Ref `json:"parent,omitempty" jsonschema:"New parent collection ID, title or path; 0 or empty moves it to the top level"`
+	DryRun bool            `json:"dry_run,omitempty" jsonschema:"Only show the plan, without moving anything"`
+}
+
+type CloneCollectionInput struct {
+	ID     collections.Ref `json:"id" jsonschema:"Collection to copy: ID, title or path"`
+	Title  string          `json:"title,omitempty" jsonschema:"Title of the copy (default: the source title followed by (copy))"`
+	Parent collections.Ref `json:"parent,omitempty" jsonschema:"Parent collection ID, title or path of the copy; 0 for the top level (default: next to the source)"`
+	DryRun bool            `json:"dry_run,omitempty" jsonschema:"Only show the plan, without copying anything"`
+}
+
+type SplitCollectionInput struct {
+	ID       collections.Ref `json:"id" jsonschema:"Collection to split: ID, title or path"`
+	By       string          `json:"by" jsonschema:"Group bookmarks by tag or by domain"`
+	MinCount int             `json:"min_count,omitempty" jsonschema:"Fewest bookmarks a group needs to get a subcollection (default 2); smaller groups stay where they are"`
+	DryRun   bool            `json:"dry_run,omitempty" jsonschema:"Only show the plan, without moving anything"`
+}
+
+// planned returns plan for a dry run, or carries it out with apply, which
+// fills in out and returns the message to show after the plan. If apply
+// fails part way, the error lists what it had already done.
+func planned(plan *impact, dryRun bool, apply func(out *PlanOutput) (string, error)) (*PlanOutput, string, error) {
+	out := &PlanOutput{Plan: plan}
+	if dryRun {
+		return out, "Dry run, nothing was changed.\n\n" + plan.String() + "\nCall again without dry_run to carry out the plan.", nil
+	}
+	message, err := apply(out)
+	if err != nil {
+		return out, "", fmt.Errorf("%w%s", err, out.partial())
+	}
+	out.Applied = true
+	return out, plan.String() + "\n" + message, nil
+}
+
+// partial describes the changes made before a plan failed
+func (o *PlanOutput) partial() string {
+	var sb strings.Builder
+	if len(o.Collections) > 0 {
+		sb.WriteString("\n\nCollections already created: " + collectionTitles(o.Collections))
+	}
+	if o.Job != nil {
+		sb.WriteString("\n\n" + o.Job.Summary())
+	}
+	return sb.String()
+}
+
+// movePlan lists the paths of the collections moved with id. It reports
+// false if id is already under parent.
+func movePlan(index *collections.Index, id, parent int) (*impact, bool, error) {
+	if id <= 0 {
+		return nil, false, errors.New("give the collection to move; system collections cannot be moved")
+	}
+	node, ok := collections.Subtree(index.Tree(), id)
+	if !ok {
+		return nil, false, fmt.Errorf("collection %d not found", id)
+	}
+	title := node.Collection.Title
+	if parent < 0 {
+		return nil, false, errors.New("collections can only be moved under another collection or to the top level")
+	}
+	if parent != 0 {
+		if _, ok := index.Get(parent); !ok {
+			return nil, false, fmt.Errorf("collection %d not found", parent)
+		}
+		if inside, ok := collections.Subtree([]*collections.Node{node}, parent); ok {
+			if parent == id {
+				return nil, false, fmt.Errorf("cannot move %q into itself", title)
+			}
+			return nil, false, fmt.Errorf("cannot move %q into %q: it is one of its own subcollections", title, "/"+inside.Path)
+		}
+	}
+
+	if collections.ParentID(node.Collection) == parent {
+		return &impact{Summary: fmt.Sprintf("%s (ID: %d) is already in %s", pathLabel(index, id), id, pathLabel(index, parent))}, false, nil
+	}
+
+	subcollections := -1 // the node itself
+	collections.Walk([]*collections.Node{node}, func(*collections.Node) { subcollections++ })
+	plan := &impact{Summary: fmt.Sprintf("Move %q (ID: %d) with %s and %s from %s to %s",
+		title, id, render.CountNoun(subcollections, "subcollection"), render.CountNoun(node.SubtreeCount, "bookmark"),
+		pathLabel(index, collections.ParentID(node.Collection)), pathLabel(index, parent))}
+
+	var prefix []string
+	if parent != 0 {
+		prefix = index.Titles(parent)
+	}
+	collections.Walk([]*collections.Node{node}, func(n *collections.Node) {
+		// The titles from the moved collection down stay the same
+		titles := index.Titles(n.Collection.FullID)
+		below := titles[max(len(titles)-1-(n.Depth-node.Depth), 0):]
+		after := strings.Join(slices.Concat(prefix, below), "/")
+		plan.Details = append(plan.Details, fmt.Sprintf("/%s → /%s", n.Path, after))
+	})
+	return plan, true, nil
+}
+
+// splitGroup is the bookmarks a split moves into one subcollection
+type splitGroup struct {
+	Name      string
+	Bookmarks []types.Raindrop
+	// Collection is the existing subcollection to reuse, or 0 to create one
+	Collection int
+}
+
+// splitMove is a bookmark a split moves and where it goes
+type splitMove struct {
+	Bookmark   types.Raindrop
+	Collection int
+}
+
+// splitGroups groups bookmarks by tag or domain. A bookmark with several
+// tags joins the group of its most common tag, or its first one when tied.
+// Groups of fewer than minCount bookmarks are dropped and their bookmarks
+// regrouped, so the bookmarks returned as staying belong to no group.
+func splitGroups(bookmarks []types.Raindrop, by string, minCount int) ([]splitGroup, []types.Raindrop) {
+	keys := make([][]string, len(bookmarks))
+	names := make(map[string]string)
+	counts := make(map[string]int)
+	for i, b := range bookmarks {
+		var candidates []string
+		if by == splitByDomain {
+			candidates = []string{bookmarkDomain(b)}
+		} else {
+			candidates = b.Tags
+		}
+		for _, name := range candidates {
+			name = strings.TrimSpace(name)
+			key := strings.ToLower(name)
+			if key == "" || slices.Contains(keys[i], key) {
+				continue
+			}
+			keys[i] = append(keys[i], key)
+			counts[key]++
+			if _, ok := names[key]; !ok {
+				names[key] = name
+			}
+		}
+	}
+
+	eligible := make(map[string]bool)
+	for key, n := range counts {
+		eligible[key] = n >= minCount
+	}
+	for {
+		members := make(map[string][]types.Raindrop)
+		var stay []types.Raindrop
+		for i, b := range bookmarks {
+			best := ""
+			for _, key := range keys[i] {
+				if eligible[key] && (best == "" || counts[key] > counts[best]) {
+					best = key
+				}
+			}
+			if best == "" {
+				stay = append(stay, b)
+				continue
+			}
+			members[best] = append(members[best], b)
+		}
+
+		// Bookmarks moving to other groups can leave a group too small
+		stable := true
+		for key, group := range members {
+			if len(group) < minCount {
+				eligible[key] = false
+				stable = false
+			}
+		}
+		if !stable {
+			continue
+		}
+
+		groups := make([]splitGroup, 0, len(members))
+		for key, group := range members {
+			groups = append(groups, splitGroup{Name: names[key], Bookmarks: group})
+		}
+		slices.SortFunc(groups, func(a, b splitGroup) int {
+			return cmp.Or(len(b.Bookmarks)-len(a.Bookmarks), strings.Compare(strings.ToLower(a.Name), strings.ToLower(b.Name)))
+		})
+		return groups, stay
+	}
+}
+
+// splitPlan describes the subcollections a split creates or fills
+func splitPlan(index *collections.Index, source types.Collection, by string, minCount int, groups []splitGroup, stay []types.Raindrop) *impact {
+	label := pathLabel(index, source.FullID)
+	if len(groups) == 0 {
+		return &impact{Summary: fmt.Sprintf("Nothing to split in %s (ID: %d): no %s is shared by at least %s",
+			label, source.FullID, by, render.CountNoun(minCount, "bookmark"))}
+	}
+
+	moved := 0
+	plan := &impact{}
+	for _, g := range groups {
+		moved += len(g.Bookmarks)
+		target := "new subcollection"
+		if g.Collection != 0 {
+			target = fmt.Sprintf("existing subcollection, ID: %d", g.Collection)
+		}
+		plan.Details = append(plan.Details, fmt.Sprintf("%q (%s): %s: %s",
+			g.Name, target, render.CountNoun(len(g.Bookmarks), "bookmark"), bookmarkTitles(g.Bookmarks)))
+	}
+	if len(stay) > 0 {
+		plan.Details = append(plan.Details, fmt.Sprintf("%s stay in %s: %s", render.CountNoun(len(stay), "bookmark"), label, bookmarkTitles(stay)))
+	}
+	plan.Summary = fmt.Sprintf("Split %s (ID: %d) by %s: move %d of %s into %s",
+		label, source.FullID, by, moved, render.CountNoun(len(stay)+moved, "bookmark"), render.CountNoun(len(groups), "subcollection"))
+	return plan
+}
+
+// contents is a collection with its subcollections and the bookmarks
+// directly in each of them
+type contents struct {
+	node      *collections.Node
+	bookmarks map[int][]types.Raindrop // collection ID -> bookmarks
+}
+
+// loadContents lists everything in a collection and its subcollections
+func loadContents(ctx context.Context, client *api.Client, index *collections.Index, id int) (*contents, error) {
+	node, ok := collections.Subtree(index.Tree(), id)
+	if !ok {
+		return nil, fmt.Errorf("collection %d not found", id)
+	}
+	c := &contents{node: node, bookmarks: make(map[int][]types.Raindrop)}
+	var err error
+	collections.Walk([]*collections.Node{node}, func(n *collections.Node) {
+		if err != nil {
+			return
+		}
+		c.bookmarks[n.Collection.FullID], err = collectionBookmarks(ctx, client, n.Collection.FullID)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+// empty reports whether the collection has no bookmarks or subcollections
+func (c *contents) empty() bool {
+	return len(c.node.Children) == 0 && len(c.bookmarks[c.node.Collection.FullID]) == 0
+}
+
+// checkTarget checks that target can receive the contents: Unsorted or a
+// collection outside the subtree
+func (c *contents) checkTarget(index *collections.Index, target int) error {
+	switch {
+	case target == collections.Unsorted:
+		return nil
+	case target <= 0:
+		return errors.New("contents can only be moved to one of your collections or to unsorted")
+	}
+	if _, ok := index.Get(target); !ok {
+		return fmt.Errorf("collection %d not found", target)
+	}
+	if _, ok := collections.Subtree([]*collections.Node{c.node}, target); ok {
+		return fmt.Errorf("cannot move the contents into %s: it is part of the collection being deleted", pathLabel(index, target))
+	}
+	return nil
+}
+
+// relocation is a bookmark or a subcollection moved out of a collection
+type relocation struct {
+	bookmark   int
+	collection int
+}
+
+// moveTo moves the collection's own bookmarks into target, and its direct
+// subcollections, with everything in them, under target, or to the top
+// level when target is Unsorted
+func (c *contents) moveTo(ctx context.Context, req *mcp.CallToolRequest, client *api.Client, target int) *jobResult {
+	var items []relocation
+	for _, b := range c.bookmarks[c.node.Collection.FullID] {
+		items = append(items, relocation{bookmark: b.ID})
+	}
+	for _, child := range c.node.Children {
+		items = append(items, relocation{collection: child.Collection.FullID})
+	}
+	parent := max(target, 0)
+	return runJob(ctx, req, items, func(item relocation) string {
+		if item.bookmark != 0 {
+			return fmt.Sprintf("bookmark %d", item.bookmark)
+		}
+		return fmt.Sprintf("collection %d", item.collection)
+	}, func(ctx context.Context, item relocation) error {
+		if item.bookmark != 0 {
+			_, err := client.UpdateRaindrop(ctx, item.bookmark, types.UpdateRaindropRequest{
+				Collection: &types.CollectionRef{ID: target},
+			})
+			return err
+		}
+		_, err := client.MoveCollection(ctx, item.collection, parent)
+		return err
+	})
+}
+
+// resolveUserCollection resolves ref to one of the user's own collections
+func resolveUserCollection(ctx context.Context, resolver *collections.Resolver, ref collections.Ref) (*collections.Index, types.Collection, error) {
+	id, err := resolver.Resolve(ctx, ref)
+	if err != nil {
+		return nil, types.Collection{}, err
+	}
+	if id <= 0 {
+		return nil, types.Collection{}, errors.New("give one of your collections; system collections are not supported")
+	}
+	index, err := resolver.Index(ctx)
+	if err != nil {
+		return nil, types.Collection{}, err
+	}
+	c, ok := index.Get(id)
+	if !ok {
+		return nil, types.Collection{}, fmt.Errorf("collection %d not found", id)
+	}
+	return index, c, nil
+}
+
+// collectionBookmarks fetches every bookmark directly in a collection
+func collectionBookmarks(ctx context.Context, client *api.Client, id int) ([]types.Raindrop, error) {
+	// Planning is quick, so only the changes themselves report progress
+	bookmarks, result, err := exportBookmarks(ctx, &progress{}, client, id, "", math.MaxInt)
+	if err != nil {
+		return nil, err
+	}
+	if result.Cancelled || result.Failed > 0 {
+		return nil, fmt.Errorf("failed to list bookmarks: %s", strings.TrimSpace(result.Summary()))
+	}
+	return bookmarks, nil
+}
+
+// cloneRequest is a request creating a collection with the visibility and
+// appearance of source
+func cloneRequest(source types.Collection, title string, parent int) types.CreateCollectionRequest {
+	req := types.CreateCollectionRequest{
+		Title:    title,
+		Public:   source.Public,
+		View:     source.View,
+		Color:    source.Color,
+		Cover:    source.Cover,
+		Sort:     &source.Sort,
+		Expanded: &source.Expanded,
+	}
+	if parent > 0 {
+		req.Parent = &types.CollectionRef{ID: parent}
+	}
+	return req
+}
+
+// copyRequest is a request creating a copy of b in a collection
+func copyRequest(b types.Raindrop, collection int) types.CreateRaindropRequest {
+	return types.CreateRaindropRequest{
+		Link:       b.Link,
+		Title:      b.Title,
+		Excerpt:    b.Excerpt,
+		Note:       b.Note,
+		Tags:       b.Tags,
+		Important:  b.Important,
+		Cover:      b.Cover,
+		Collection: &types.CollectionRef{ID: collection},
+	}
+}
+
+// bookmarkDomain is the domain of a bookmark without a leading www.
+func bookmarkDomain(b types.Raindrop) string {
+	domain := b.Domain
+	if domain == "" {
+		if u, err := url.Parse(b.Link); err == nil {
+			domain = u.Hostname()
+		}
+	}
+	return strings.TrimPrefix(strings.ToLower(domain), "www.")
+}
+
+func describeBookmark(b types.Raindrop) string {
+	return fmt.Sprintf("bookmark %d", b.ID)
+}
+
+// bookmarkTitles lists the titles of bookmarks for a plan, naming at most
+// maxPlanItems of them
+func bookmarkTitles(bookmarks []types.Raindrop) string {
+	var titles []string
+	for _, b := range bookmarks[:min(len(bookmarks), maxPlanItems)] {
+		titles = append(titles, fmt.Sprintf("%q", b.Title))
+	}
+	if len(bookmarks) > maxPlanItems {
+		titles = append(titles, fmt.Sprintf("and %d more", len(bookmarks)-maxPlanItems))
+	}
+	return strings.Join(titles, ", ")
+}
+
+// pathLabel names a collection by its path, or the top level for 0
+func pathLabel(index *collections.Index, id int) string {
+	if id == 0 {
+		return "the top level"
+	}
+	return fmt.Sprintf("%q", "/"+index.Path(id))
+}
@@ -0,0 +1,165 @@
+package tools
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+	"testing"
+
+	"raindrop-mcp/collections"
+	"raindrop-mcp/types"
+)
+
+// groupSummary writes groups as "name:id,id" and the bookmarks that stay as
+// "stay:id,id", for comparing splits
+func groupSummary(groups []splitGroup, stay []types.Raindrop) []string {
+	ids := func(bookmarks []types.Raindrop) string {
+		parts := make([]string, len(bookmarks))
+		for i, b := range bookmarks {
+			parts[i] = fmt.Sprint(b.ID)
+		}
+		return strings.Join(parts, ",")
+	}
+	var summary []string
+	for _, g := range groups {
+		summary = append(summary, g.Name+":"+ids(g.Bookmarks))
+	}
+	return append(summary, "stay:"+ids(stay))
+}
+
+func tagged(id int, tags ...string) types.Raindrop {
+	return types.Raindrop{ID: id, Tags: tags}
+}
+
+func linked(id int, link string) types.Raindrop {
+	return types.Raindrop{ID: id, Link: link}
+}
+
+func TestSplitGroups(t *testing.T) {
+	tests := []struct {
+		name      string
+		bookmarks []types.Raindrop
+		by        string
+		minCount  int
+		want      []string
+	}{
+		{
+			name:      "most common tag wins",
+			bookmarks: []types.Raindrop{tagged(1, "go"), tagged(2, "web", "go"), tagged(3, "go"), tagged(4, "web")},
+			by:        splitByTag,
+			minCount:  1,
+			want:      []string{"go:1,2,3", "web:4", "stay:"},
+		},
+		{
+			name:      "first tag breaks ties",
+			bookmarks: []types.Raindrop{tagged(1, "b", "a"), tagged(2, "a"), tagged(3, "b")},
+			by:        splitByTag,
+			minCount:  1,
+			want:      []string{"b:1,3", "a:2", "stay:"},
+		},
+		{
+			name:      "tags compared case-insensitively",
+			bookmarks: []types.Raindrop{tagged(1, "Go"), tagged(2, "go", "GO"), tagged(3)},
+			by:        splitByTag,
+			minCount:  2,
+			want:      []string{"Go:1,2", "stay:3"},
+		},
+		{
+			name:      "small groups stay",
+			bookmarks: []types.Raindrop{tagged(1, "go"), tagged(2, "go"), tagged(3, "rust")},
+			by:        splitByTag,
+			minCount:  2,
+			want:      []string{"go:1,2", "stay:3"},
+		},
+		{
+			// c has two bookmarks, but one joins the larger b group, which
+			// leaves c too small
+			name:      "groups shrunk below the minimum are regrouped",
+			bookmarks: []types.Raindrop{tagged(1, "a", "b"), tagged(2, "b"), tagged(3, "b", "c"), tagged(4, "c")},
+			by:        splitByTag,
+			minCount:  2,
+			want:      []string{"b:1,2,3", "stay:4"},
+		},
+		{
+			name:      "nothing qualifies",
+			bookmarks: []types.Raindrop{tagged(1, "a"), tagged(2, "b")},
+			by:        splitByTag,
+			minCount:  2,
+			want:      []string{"stay:1,2"},
+		},
+		{
+			name: "by domain",
+			bookmarks: []types.Raindrop{
+				linked(1, "https://www.Example.com/a"),
+				linked(2, "https://example.com/b"),
+				linked(3, "https://other.org/"),
+				{ID: 4, Link: "https://ignored.net/", Domain: "example.com"},
+			},
+			by:       splitByDomain,
+			minCount: 2,
+			want:     []string{"example.com:1,2,4", "stay:3"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			groups, stay := splitGroups(tt.bookmarks, tt.by, tt.minCount)
+			if got := groupSummary(groups, stay); !slices.Equal(got, tt.want) {
+				t.Errorf("splitGroups() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMovePlan(t *testing.T) {
+	child := func(id int, title string, parent int) types.Collection {
+		return types.Collection{FullID: id, Title: title, Parent: &types.Parent{ID: parent}}
+	}
+	index := collections.NewIndex([]types.Collection{
+		{FullID: 5, Title: "Work"},
+		{FullID: 6, Title: "Reading"},
+		child(7, "Go", 5),
+		child(8, "Deep", 7),
+	})
+
+	tests := []struct {
+		name        string
+		id, parent  int
+		wantMoves   bool
+		wantDetails []string
+		wantErr     string
+	}{
+		{
+			name: "to another collection", id: 7, parent: 6, wantMoves: true,
+			wantDetails: []string{"/Work/Go → /Reading/Go", "/Work/Go/Deep → /Reading/Go/Deep"},
+		},
+		{
+			name: "to the top level", id: 8, parent: 0, wantMoves: true,
+			wantDetails: []string{"/Work/Go/Deep → /Deep"},
+		},
+		{name: "already there", id: 7, parent: 5},
+		{name: "into itself", id: 5, parent: 5, wantErr: "into itself"},
+		{name: "into its child", id: 5, parent: 7, wantErr: `into "/Work/Go": it is one of its own subcollections`},
+		{name: "into a deeper descendant", id: 5, parent: 8, wantErr: "one of its own subcollections"},
+		{name: "system collection", id: collections.Unsorted, parent: 5, wantErr: "system collections cannot be moved"},
+		{name: "to unsorted", id: 7, parent: collections.Unsorted, wantErr: "under another collection or to the top level"},
+		{name: "unknown collection", id: 99, parent: 5, wantErr: "collection 99 not found"},
+		{name: "unknown parent", id: 7, parent: 99, wantErr: "collection 99 not found"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			plan, moves, err := movePlan(index, tt.id, tt.parent)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("movePlan(%d, %d) error = %v, want one containing %q", tt.id, tt.parent, err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if moves != tt.wantMoves || !slices.Equal(plan.Details, tt.wantDetails) {
+				t.Errorf("movePlan(%d, %d) = %v, %v; want %v, %v", tt.id, tt.parent, moves, plan.Details, tt.wantMoves, tt.wantDetails)
+			}
+		})
+	}
+}
@@ -2,136 +2,495 @@ package tools
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"log/slog"
+	"reflect"
+	"strconv"
 	"strings"
+	"time"
 
+	"raindrop-mcp/accounts"
 	"raindrop-mcp/api"
+	"raindrop-mcp/collections"
+	"raindrop-mcp/logging"
+	"raindrop-mcp/policy"
+	"raindrop-mcp/render"
 	"raindrop-mcp/types"
 
 	"github.com/modelcontextprotocol/go-sdk/mcp"
 )
 
-// Output type for tools that return text
-type TextOutput struct {
-	Text string `json:"text"`
+// Options configures tool behavior
+type Options struct {
+	// DefaultCollection is used by create-bookmark when no collection is given
+	DefaultCollection int
+	// DefaultPerPage is used by search-bookmarks when no page size is given
+	DefaultPerPage int
+	// OutputFormat is the default format of listings (see render.Formats)
+	OutputFormat string
+	// Policy selects the tools to register; nil registers all tools
+	Policy *policy.Policy
+}
+
+// Registry registers tools on a server, applying Options to each of them
+type Registry struct {
+	server   *mcp.Server
+	accounts *accounts.Manager
+	opts     Options
+
+	known      []string
+	registered []string
+	readable   map[string]bool
+	invalid    []error
+	confirms   *confirmations
+}
+
+// NewRegistry creates a registry for server
+func NewRegistry(server *mcp.Server, accounts *accounts.Manager, opts Options) *Registry {
+	return &Registry{
+		server:   server,
+		accounts: accounts,
+		opts:     opts,
+		readable: make(map[string]bool),
+		confirms: newConfirmations(),
+	}
+}
+
+// Registered returns the names of registered tools
+func (r *Registry) Registered() []string {
+	return r.registered
+}
+
+// Exposes reports whether a registered read-only tool serves category.
+// Resources use it so they never reveal more than the allowed tools.
+func (r *Registry) Exposes(category string) bool {
+	return r.readable[category]
+}
+
+// Validate reports tools registered without complete annotations and policy
+// entries that match no tool or category. Call it after all tools have been added.
+func (r *Registry) Validate() error {
+	errs := r.invalid
+	if unknown := r.opts.Policy.Unknown(r.known); len(unknown) > 0 {
+		errs = append(errs, fmt.Errorf("unknown tools or categories in tool policy: %s", strings.Join(unknown, ", ")))
+	}
+	return errors.Join(errs...)
+}
+
+// add records a tool and reports whether it should be registered.
+// Tools must declare their safety annotations; the policy decides the rest.
+func (r *Registry) add(tool *mcp.Tool, category string) bool {
+	r.known = append(r.known, tool.Name)
+	if err := checkAnnotations(tool); err != nil {
+		r.invalid = append(r.invalid, err)
+		return false
+	}
+
+	readOnly := tool.Annotations.ReadOnlyHint
+	if !r.opts.Policy.AllowsTool(tool.Name, category, readOnly) {
+		return false
+	}
+	r.registered = append(r.registered, tool.Name)
+	if readOnly {
+		r.readable[category] = true
+	}
+	return true
+}
+
+// checkAnnotations ensures clients get every safety hint for a tool.
+// ReadOnlyHint and IdempotentHint default to false, so only the pointer
+// fields and the title can be checked for presence.
+func checkAnnotations(tool *mcp.Tool) error {
+	a := tool.Annotations
+	switch {
+	case a == nil:
+		return fmt.Errorf("tool %s: missing annotations", tool.Name)
+	case a.Title == "":
+		return fmt.Errorf("tool %s: missing title annotation", tool.Name)
+	case a.DestructiveHint == nil:
+		return fmt.Errorf("tool %s: missing destructiveHint annotation", tool.Name)
+	case a.OpenWorldHint == nil:
+		return fmt.Errorf("tool %s: missing openWorldHint annotation", tool.Name)
+	case a.ReadOnlyHint && *a.DestructiveHint:
+		return fmt.Errorf("tool %s: a read-only tool cannot be destructive", tool.Name)
+	}
+	return nil
+}
+
+// boolPtr returns a pointer to b, for optional annotation fields
+func boolPtr(b bool) *bool {
+	return &b
+}
+
+// Listing selects how a listing tool renders its text
+type Listing struct {
+	Format string   `json:"format,omitempty" jsonschema:"Text format: markdown, json, csv or compact (default from configuration)"`
+	Fields []string `json:"fields,omitempty" jsonschema:"Fields to show, in order; 'all' for every field. Unknown names are rejected with the valid ones"`
+}
+
+// renderOptions applies the configured default format to l
+func (r *Registry) renderOptions(l Listing) render.Options {
+	format := l.Format
+	if format == "" {
+		format = r.opts.OutputFormat
+	}
+	return render.Options{Format: format, Fields: l.Fields}
+}
+
+// handlerFunc is a tool handler bound to the calling session's account. It
+// returns the structured output and its human-readable text.
+type handlerFunc[In any, Out output] func(ctx context.Context, req *mcp.CallToolRequest, client *api.Client, input In) (Out, string, error)
+
+// addTool registers a tool that runs against the session's active account
+// and reports which account it acted on
+func addTool[In any, Out output](r *Registry, category string, tool *mcp.Tool, h handlerFunc[In, Out]) {
+	if !r.add(tool, category) || !setInputSchema[In](r, tool) || !setOutputSchema[Out](r, tool) {
+		return
+	}
+	mcp.AddTool(r.server, tool, func(ctx context.Context, req *mcp.CallToolRequest, input In) (*mcp.CallToolResult, Out, error) {
+		var none Out
+
+		// Logs from the call go to the calling session only
+		ctx = logging.WithSession(ctx, req.Session)
+		account, err := r.accounts.ForRequest(req)
+		if err != nil {
+			slog.WarnContext(ctx, "Tool call rejected", "tool", tool.Name, "error", err)
+			return nil, none, err
+		}
+
+		start := time.Now()
+		out, text, err := h(ctx, req, account.Client, input)
+		if err != nil {
+			slog.WarnContext(ctx, "Tool call failed", "tool", tool.Name, "account", account.Profile, "error", err)
+			return nil, none, err
+		}
+		slog.DebugContext(ctx, "Tool call succeeded", "tool", tool.Name, "account", account.Profile, "duration", time.Since(start))
+		return textResult(out, text, account.Label(ctx))
+	})
+}
+
+// textResult completes a tool's output with the account it acted on and
+// returns it with text as the human-readable content
+func textResult[Out output](out Out, text, account string) (*mcp.CallToolResult, Out, error) {
+	out.setAccount(account)
+	return &mcp.CallToolResult{
+		Content: []mcp.Content{&mcp.TextContent{Text: strings.TrimRight(text, "\n") + "\n\nAccount: " + account}},
+	}, out, nil
 }
 
 // RegisterTools registers all Raindrop tools with the MCP server
-func RegisterTools(server *mcp.Server, client *api.Client) {
+func RegisterTools(r *Registry) {
 	// create-bookmark
-	mcp.AddTool(server, &mcp.Tool{
+	addTool(r, policy.Bookmarks, &mcp.Tool{
 		Name:        "create-bookmark",
 		Description: "Create a new bookmark in Raindrop.io",
-	}, func(ctx context.Context, req *mcp.CallToolRequest, input CreateBookmarkInput) (*mcp.CallToolResult, TextOutput, error) {
-		raindrop, err := client.CreateRaindrop(input.URL, input.Title, input.Tags, input.Collection)
+		Annotations: &mcp.ToolAnnotations{
+			Title:           "Create Bookmark",
+			ReadOnlyHint:    false,
+			DestructiveHint: boolPtr(false),
+			IdempotentHint:  false,
+			OpenWorldHint:   boolPtr(true),
+		},
+	}, func(ctx context.Context, req *mcp.CallToolRequest, client *api.Client, input CreateBookmarkInput) (*BookmarkOutput, string, error) {
+		collection := r.opts.DefaultCollection
+		if input.Collection != "" {
+			var err error
+			if collection, err = collections.NewResolver(client).Resolve(ctx, input.Collection); err != nil {
+				return nil, "", err
+			}
+		}
+		raindrop, err := client.CreateRaindrop(ctx, input.URL, input.Title, input.Tags, collection)
 		if err != nil {
-			return nil, TextOutput{}, fmt.Errorf("failed to create bookmark: %w", err)
+			return nil, "", fmt.Errorf("failed to create bookmark: %w", err)
 		}
-		return nil, TextOutput{Text: formatRaindrop(raindrop)}, nil
+		return &BookmarkOutput{Bookmark: *raindrop}, formatRaindrop(raindrop), nil
 	})
 
 	// get-bookmark
-	mcp.AddTool(server, &mcp.Tool{
+	addTool(r, policy.Bookmarks, &mcp.Tool{
 		Name:        "get-bookmark",
 		Description: "Get a bookmark by its ID",
-	}, func(ctx context.Context, req *mcp.CallToolRequest, input GetBookmarkInput) (*mcp.CallToolResult, TextOutput, error) {
-		raindrop, err := client.GetRaindrop(input.ID)
+		Annotations: &mcp.ToolAnnotations{
+			Title:           "Get Bookmark",
+			ReadOnlyHint:    true,
+			DestructiveHint: boolPtr(false),
+			IdempotentHint:  true,
+			OpenWorldHint:   boolPtr(false),
+		},
+	}, func(ctx context.Context, req *mcp.CallToolRequest, client *api.Client, input GetBookmarkInput) (*BookmarkOutput, string, error) {
+		raindrop, err := client.GetRaindrop(ctx, input.ID)
 		if err != nil {
-			return nil, TextOutput{}, fmt.Errorf("failed to get bookmark: %w", err)
+			return nil, "", fmt.Errorf("failed to get bookmark: %w", err)
 		}
-		return nil, TextOutput{Text: formatRaindrop(raindrop)}, nil
+		return &BookmarkOutput{Bookmark: *raindrop}, formatRaindrop(raindrop), nil
 	})
 
 	// update-bookmark
-	mcp.AddTool(server, &mcp.Tool{
+	addTool(r, policy.Bookmarks, &mcp.Tool{
 		Name:        "update-bookmark",
 		Description: "Update an existing bookmark",
-	}, func(ctx context.Context, req *mcp.CallToolRequest, input UpdateBookmarkInput) (*mcp.CallToolResult, TextOutput, error) {
-		var collectionPtr *int
-		if input.Collection != 0 {
-			collectionPtr = &input.Collection
+		Annotations: &mcp.ToolAnnotations{
+			Title:           "Update Bookmark",
+			ReadOnlyHint:    false,
+			DestructiveHint: boolPtr(true),
+			IdempotentHint:  true,
+			OpenWorldHint:   boolPtr(false),
+		},
+	}, func(ctx context.Context, req *mcp.CallToolRequest, client *api.Client, input UpdateBookmarkInput) (*UpdateBookmarkOutput, string, error) {
+		patch, err := input.patch(ctx, collections.NewResolver(client))
+		if err != nil {
+			return nil, "", err
+		}
+		before, err := client.GetRaindropFresh(ctx, input.ID)
+		if err != nil {
+			return nil, "", fmt.Errorf("failed to get bookmark: %w", err)
+		}
+		if input.editsTags() {
+			tags := editTags(before.Tags, input.AddTags, input.RemoveTags)
+			patch.Tags = &tags
 		}
-		raindrop, err := client.UpdateRaindrop(input.ID, input.Title, input.Note, input.Tags, collectionPtr)
+		after, err := client.UpdateRaindrop(ctx, input.ID, patch)
 		if err != nil {
-			return nil, TextOutput{}, fmt.Errorf("failed to update bookmark: %w", err)
+			return nil, "", fmt.Errorf("failed to update bookmark: %w", err)
 		}
-		return nil, TextOutput{Text: formatRaindrop(raindrop)}, nil
+
+		changes := diffRaindrops(before, after)
+		return &UpdateBookmarkOutput{Bookmark: *after, Changes: changes}, formatRaindrop(after) + "\n" + formatChanges(changes), nil
 	})
 
 	// delete-bookmark
-	mcp.AddTool(server, &mcp.Tool{
+	addTool(r, policy.Bookmarks, &mcp.Tool{
 		Name:        "delete-bookmark",
 		Description: "Delete a bookmark (moves to Trash)",
-	}, func(ctx context.Context, req *mcp.CallToolRequest, input DeleteBookmarkInput) (*mcp.CallToolResult, TextOutput, error) {
-		err := client.DeleteRaindrop(input.ID)
+		Annotations: &mcp.ToolAnnotations{
+			Title:           "Delete Bookmark",
+			ReadOnlyHint:    false,
+			DestructiveHint: boolPtr(true),
+			IdempotentHint:  false,
+			OpenWorldHint:   boolPtr(false),
+		},
+	}, func(ctx context.Context, req *mcp.CallToolRequest, client *api.Client, input DeleteBookmarkInput) (*ChangeOutput, string, error) {
+		err := client.DeleteRaindrop(ctx, input.ID)
 		if err != nil {
-			return nil, TextOutput{}, fmt.Errorf("failed to delete bookmark: %w", err)
+			return nil, "", fmt.Errorf("failed to delete bookmark: %w", err)
 		}
-		return nil, TextOutput{Text: fmt.Sprintf("Bookmark %d deleted successfully", input.ID)}, nil
+		return changed(fmt.Sprintf("Bookmark %d deleted successfully", input.ID))
 	})
 
 	// search-bookmarks
-	mcp.AddTool(server, &mcp.Tool{
+	addTool(r, policy.Bookmarks, &mcp.Tool{
 		Name:        "search-bookmarks",
 		Description: "Search through your Raindrop.io bookmarks",
-	}, func(ctx context.Context, req *mcp.CallToolRequest, input SearchBookmarksInput) (*mcp.CallToolResult, TextOutput, error) {
-		result, err := client.SearchRaindrops(input.Query, input.Collection, input.Page, input.PerPage, input.Tags)
+		Annotations: &mcp.ToolAnnotations{
+			Title:           "Search Bookmarks",
+			ReadOnlyHint:    true,
+			DestructiveHint: boolPtr(false),
+			IdempotentHint:  true,
+			OpenWorldHint:   boolPtr(false),
+		},
+	}, func(ctx context.Context, req *mcp.CallToolRequest, client *api.Client, input SearchBookmarksInput) (*BookmarksOutput, string, error) {
+		perPage := input.PerPage
+		if perPage == 0 {
+			perPage = r.opts.DefaultPerPage
+		}
+		collection, err := collections.NewResolver(client).Resolve(ctx, input.Collection)
 		if err != nil {
-			return nil, TextOutput{}, fmt.Errorf("failed to search bookmarks: %w", err)
+			return nil, "", err
 		}
-		return nil, TextOutput{Text: formatRaindrops(result)}, nil
+		result, err := client.SearchRaindrops(ctx, input.Query, collection, input.Page, perPage, input.Tags)
+		if err != nil {
+			return nil, "", fmt.Errorf("failed to search bookmarks: %w", err)
+		}
+		text, err := render.Bookmarks(result.Items, result.Count, r.renderOptions(input.Listing))
+		if err != nil {
+			return nil, "", err
+		}
+		return &BookmarksOutput{Count: result.Count, Bookmarks: result.Items}, text, nil
 	})
 
 	// list-collections
-	mcp.AddTool(server, &mcp.Tool{
+	addTool(r, policy.Collections, &mcp.Tool{
 		Name:        "list-collections",
 		Description: "List all your Raindrop.io collections",
-	}, func(ctx context.Context, req *mcp.CallToolRequest, input struct{}) (*mcp.CallToolResult, TextOutput, error) {
-		rootCollections, err := client.ListCollections()
+		Annotations: &mcp.ToolAnnotations{
+			Title:           "List Collections",
+			ReadOnlyHint:    true,
+			DestructiveHint: boolPtr(false),
+			IdempotentHint:  true,
+			OpenWorldHint:   boolPtr(false),
+		},
+	}, func(ctx context.Context, req *mcp.CallToolRequest, client *api.Client, input ListCollectionsInput) (*CollectionsOutput, string, error) {
+		index, err := collections.Load(ctx, client)
+		if err != nil {
+			return nil, "", err
+		}
+
+		allCollections := index.Collections
+		text, err := render.Collections(allCollections, r.renderOptions(input.Listing))
+		if err != nil {
+			return nil, "", err
+		}
+		return &CollectionsOutput{Collections: allCollections}, text, nil
+	})
+
+	// collection-tree
+	addTool(r, policy.Collections, &mcp.Tool{
+		Name:        "collection-tree",
+		Description: "Show collections as a tree, with bookmark counts for each collection and its subcollections",
+		Annotations: &mcp.ToolAnnotations{
+			Title:           "Collection Tree",
+			ReadOnlyHint:    true,
+			DestructiveHint: boolPtr(false),
+			IdempotentHint:  true,
+			OpenWorldHint:   boolPtr(false),
+		},
+	}, func(ctx context.Context, req *mcp.CallToolRequest, client *api.Client, input CollectionTreeInput) (*CollectionTreeOutput, string, error) {
+		if input.Depth < 0 {
+			return nil, "", fmt.Errorf("depth must not be negative")
+		}
+		resolver := collections.NewResolver(client)
+		index, err := resolver.Index(ctx)
 		if err != nil {
-			return nil, TextOutput{}, fmt.Errorf("failed to list collections: %w", err)
+			return nil, "", err
 		}
-		childCollections, err := client.ListChildCollections()
+		tree := index.Tree()
+		if input.Root != "" {
+			id, err := resolver.Resolve(ctx, input.Root)
+			if err != nil {
+				return nil, "", err
+			}
+			node, ok := collections.Subtree(tree, id)
+			if !ok {
+				return nil, "", fmt.Errorf("collection %d not found", id)
+			}
+			tree = []*collections.Node{node}
+		}
+
+		format := input.Format
+		if format == "" {
+			format = render.TreeFormat(r.opts.OutputFormat)
+		}
+		text, err := render.Tree(tree, input.Depth, render.Options{Format: format})
 		if err != nil {
-			return nil, TextOutput{}, fmt.Errorf("failed to list child collections: %w", err)
+			return nil, "", err
 		}
 
-		allCollections := append(rootCollections.Items, childCollections.Items...)
-		return nil, TextOutput{Text: formatCollections(allCollections)}, nil
+		out := &CollectionTreeOutput{Collections: []TreeEntry{}}
+		collections.Walk(tree, func(n *collections.Node) {
+			if input.Depth > 0 && n.Depth-tree[0].Depth >= input.Depth {
+				return
+			}
+			out.Collections = append(out.Collections, TreeEntry{
+				ID:           n.Collection.FullID,
+				Title:        n.Collection.Title,
+				Path:         n.Path,
+				Parent:       collections.ParentID(n.Collection),
+				Depth:        n.Depth,
+				Count:        n.Collection.Count,
+				SubtreeCount: n.SubtreeCount,
+			})
+		})
+		return out, text, nil
 	})
 
 	// list-tags
-	mcp.AddTool(server, &mcp.Tool{
+	addTool(r, policy.Tags, &mcp.Tool{
 		Name:        "list-tags",
 		Description: "List all tags in your Raindrop.io account",
-	}, func(ctx context.Context, req *mcp.CallToolRequest, input ListTagsInput) (*mcp.CallToolResult, TextOutput, error) {
-		tagsResp, err := client.GetTags(input.Collection)
+		Annotations: &mcp.ToolAnnotations{
+			Title:           "List Tags",
+			ReadOnlyHint:    true,
+			DestructiveHint: boolPtr(false),
+			IdempotentHint:  true,
+			OpenWorldHint:   boolPtr(false),
+		},
+	}, func(ctx context.Context, req *mcp.CallToolRequest, client *api.Client, input ListTagsInput) (*TagsOutput, string, error) {
+		collection, err := collections.NewResolver(client).Resolve(ctx, input.Collection)
 		if err != nil {
-			return nil, TextOutput{}, fmt.Errorf("failed to list tags: %w", err)
+			return nil, "", err
 		}
-		return nil, TextOutput{Text: formatTags(tagsResp.Items)}, nil
+		tagsResp, err := client.GetTags(ctx, collection)
+		if err != nil {
+			return nil, "", fmt.Errorf("failed to list tags: %w", err)
+		}
+		text, err := render.Tags(tagsResp.Items, r.renderOptions(input.Listing))
+		if err != nil {
+			return nil, "", err
+		}
+		return &TagsOutput{Tags: tagsResp.Items}, text, nil
 	})
 }
 
 // Input types
 
 type CreateBookmarkInput struct {
-	URL        string   `json:"url" jsonschema:"URL to bookmark"`
-	Title      string   `json:"title,omitempty" jsonschema:"Title for the bookmark"`
-	Tags       []string `json:"tags,omitempty" jsonschema:"Tags for the bookmark"`
-	Collection int      `json:"collection,omitempty" jsonschema:"Collection ID to save to (0 for Unsorted)"`
+	URL        string          `json:"url" jsonschema:"URL to bookmark"`
+	Title      string          `json:"title,omitempty" jsonschema:"Title for the bookmark"`
+	Tags       []string        `json:"tags,omitempty" jsonschema:"Tags for the bookmark"`
+	Collection collections.Ref `json:"collection,omitempty" jsonschema:"Collection to save to: ID, title or path such as Work/Go (default Unsorted)"`
 }
 
 type GetBookmarkInput struct {
 	ID int `json:"id" jsonschema:"Bookmark ID"`
 }
 
+// UpdateBookmarkInput changes only the fields that are given; an empty
+// string or tag list clears the field
 type UpdateBookmarkInput struct {
-	ID         int      `json:"id" jsonschema:"Bookmark ID to update"`
-	Title      string   `json:"title,omitempty" jsonschema:"New title"`
-	Note       string   `json:"note,omitempty" jsonschema:"Note/description"`
-	Tags       []string `json:"tags,omitempty" jsonschema:"New tags (replaces existing)"`
-	Collection int      `json:"collection,omitempty" jsonschema:"Move to collection ID"`
+	ID         int             `json:"id" jsonschema:"Bookmark ID to update"`
+	Title      *string         `json:"title,omitempty" jsonschema:"New title; empty clears it"`
+	Link       *string         `json:"link,omitempty" jsonschema:"New URL"`
+	Excerpt    *string         `json:"excerpt,omitempty" jsonschema:"New excerpt; empty clears it"`
+	Note       *string         `json:"note,omitempty" jsonschema:"Note/description; empty clears it"`
+	Tags       []string        `json:"tags,omitempty" jsonschema:"New tags (replaces existing); empty list removes all tags"`
+	AddTags    []string        `json:"add_tags,omitempty" jsonschema:"Tags to add to the existing ones"`
+	RemoveTags []string        `json:"remove_tags,omitempty" jsonschema:"Tags to remove, ignoring case"`
+	Important  *bool           `json:"important,omitempty" jsonschema:"Mark or unmark as favorite"`
+	Cover      *string         `json:"cover,omitempty" jsonschema:"Cover image URL; empty clears it"`
+	Collection collections.Ref `json:"collection,omitempty" jsonschema:"Move to collection: ID, title, path or unsorted"`
+}
+
+// editsTags reports whether the input adds or removes individual tags
+func (in UpdateBookmarkInput) editsTags() bool {
+	return len(in.AddTags) > 0 || len(in.RemoveTags) > 0
+}
+
+// patch converts the input into an update request. Tag additions and
+// removals are applied to the current tags by the caller.
+func (in UpdateBookmarkInput) patch(ctx context.Context, resolver *collections.Resolver) (types.UpdateRaindropRequest, error) {
+	patch := types.UpdateRaindropRequest{
+		Link:      in.Link,
+		Title:     in.Title,
+		Excerpt:   in.Excerpt,
+		Note:      in.Note,
+		Important: in.Important,
+		Cover:     in.Cover,
+	}
+	if in.Link != nil && strings.TrimSpace(*in.Link) == "" {
+		return patch, errors.New("link cannot be empty")
+	}
+	if in.Tags != nil && in.editsTags() {
+		return patch, errors.New("give either tags or add_tags/remove_tags, not both")
+	}
+	// A JSON [] decodes to an empty, non-nil slice, which clears the tags
+	if in.Tags != nil {
+		patch.Tags = &in.Tags
+	}
+	if in.Collection != "" {
+		id, err := resolver.Resolve(ctx, in.Collection)
+		if err != nil {
+			return patch, err
+		}
+		if id == collections.All {
+			return patch, errors.New("a bookmark cannot be moved to all; give a collection, or unsorted")
+		}
+		patch.Collection = &types.CollectionRef{ID: id}
+	}
+	if patch == (types.UpdateRaindropRequest{}) && !in.editsTags() {
+		return patch, errors.New("nothing to update: give at least one field to change")
+	}
+	return patch, nil
 }
 
 type DeleteBookmarkInput struct {
@@ -139,88 +498,98 @@ type DeleteBookmarkInput struct {
 }
 
 type SearchBookmarksInput struct {
-	Query      string   `json:"query" jsonschema:"Search query"`
-	Collection int      `json:"collection,omitempty" jsonschema:"Collection ID (0 for all)"`
-	Tags       []string `json:"tags,omitempty" jsonschema:"Filter by tags"`
-	Page       int      `json:"page,omitempty" jsonschema:"Page number (0-based)"`
-	PerPage    int      `json:"perpage,omitempty" jsonschema:"Items per page (max 50)"`
+	Query      string          `json:"query" jsonschema:"Search query"`
+	Collection collections.Ref `json:"collection,omitempty" jsonschema:"Collection ID, title or path (default all)"`
+	Tags       []string        `json:"tags,omitempty" jsonschema:"Filter by tags"`
+	Page       int             `json:"page,omitempty" jsonschema:"Page number (0-based)"`
+	PerPage    int             `json:"perpage,omitempty" jsonschema:"Items per page (max 50)"`
+	Listing
 }
 
-type ListTagsInput struct {
-	Collection int `json:"collection,omitempty" jsonschema:"Collection ID (0 for all tags)"`
+type ListCollectionsInput struct {
+	Listing
 }
 
-// Formatting helpers
+type CollectionTreeInput struct {
+	Root   collections.Ref `json:"root,omitempty" jsonschema:"Show only this collection and its subcollections: ID, title or path"`
+	Depth  int             `json:"depth,omitempty" jsonschema:"Levels to show, 1 for the top level only (default all)"`
+	Format string          `json:"format,omitempty" jsonschema:"markdown or json (default from configuration)"`
+}
 
-func formatRaindrop(r *types.Raindrop) string {
-	var sb strings.Builder
-	sb.WriteString(fmt.Sprintf("**%s**\n", r.Title))
-	sb.WriteString(fmt.Sprintf("ID: %d\n", r.ID))
-	sb.WriteString(fmt.Sprintf("URL: %s\n", r.Link))
-	if len(r.Tags) > 0 {
-		sb.WriteString(fmt.Sprintf("Tags: %s\n", strings.Join(r.Tags, ", ")))
-	}
-	if r.Excerpt != "" {
-		sb.WriteString(fmt.Sprintf("Excerpt: %s\n", r.Excerpt))
-	}
-	if r.Note != "" {
-		sb.WriteString(fmt.Sprintf("Note: %s\n", r.Note))
-	}
-	sb.WriteString(fmt.Sprintf("Created: %s\n", r.Created))
-	return sb.String()
+type ListTagsInput struct {
+	Collection collections.Ref `json:"collection,omitempty" jsonschema:"Collection ID, title or path (default all tags)"`
+	Listing
 }
 
-func formatRaindrops(resp *types.RaindropsResponse) string {
-	if len(resp.Items) == 0 {
-		return "No bookmarks found."
-	}
+// Formatting helpers
 
-	var sb strings.Builder
-	sb.WriteString(fmt.Sprintf("Found %d bookmarks:\n\n", resp.Count))
+// raindropFields are the writable bookmark fields compared by diffRaindrops
+var raindropFields = []struct {
+	name  string
+	value func(r *types.Raindrop) any
+}{
+	{"title", func(r *types.Raindrop) any { return r.Title }},
+	{"link", func(r *types.Raindrop) any { return r.Link }},
+	{"excerpt", func(r *types.Raindrop) any { return r.Excerpt }},
+	{"note", func(r *types.Raindrop) any { return r.Note }},
+	{"tags", func(r *types.Raindrop) any { return append([]string{}, r.Tags...) }},
+	{"important", func(r *types.Raindrop) any { return r.Important }},
+	{"cover", func(r *types.Raindrop) any { return r.Cover }},
+	{"collection", func(r *types.Raindrop) any { return r.Collection.ID }},
+}
 
-	for i, r := range resp.Items {
-		sb.WriteString(fmt.Sprintf("%d. **%s**\n", i+1, r.Title))
-		sb.WriteString(fmt.Sprintf("   ID: %d | URL: %s\n", r.ID, r.Link))
-		if len(r.Tags) > 0 {
-			sb.WriteString(fmt.Sprintf("   Tags: %s\n", strings.Join(r.Tags, ", ")))
+// diffRaindrops lists the writable fields that differ between two versions
+// of a bookmark
+func diffRaindrops(before, after *types.Raindrop) []FieldChange {
+	changes := []FieldChange{}
+	for _, f := range raindropFields {
+		old, updated := f.value(before), f.value(after)
+		if !reflect.DeepEqual(old, updated) {
+			changes = append(changes, FieldChange{Field: f.name, Before: old, After: updated})
 		}
-		sb.WriteString("\n")
 	}
-
-	return sb.String()
+	return changes
 }
 
-func formatCollections(collections []types.Collection) string {
-	if len(collections) == 0 {
-		return "No collections found."
+func formatChanges(changes []FieldChange) string {
+	if len(changes) == 0 {
+		return "No fields changed.\n"
 	}
-
 	var sb strings.Builder
-	sb.WriteString(fmt.Sprintf("Found %d collections:\n\n", len(collections)))
-
-	for _, c := range collections {
-		indent := ""
-		if c.Parent != nil && c.Parent.ID > 0 {
-			indent = "  └─ "
-		}
-		sb.WriteString(fmt.Sprintf("%s**%s** (ID: %d)\n", indent, c.Title, c.FullID))
-		sb.WriteString(fmt.Sprintf("%s   %d bookmarks\n\n", indent, c.Count))
+	sb.WriteString("Changed:\n")
+	for _, c := range changes {
+		sb.WriteString(fmt.Sprintf("- %s: %s → %s\n", c.Field, formatValue(c.Before), formatValue(c.After)))
 	}
-
 	return sb.String()
 }
 
-func formatTags(tags []types.Tag) string {
-	if len(tags) == 0 {
-		return "No tags found."
+func formatValue(v any) string {
+	switch v := v.(type) {
+	case string:
+		return strconv.Quote(v)
+	case []string:
+		if len(v) == 0 {
+			return "(none)"
+		}
+		return strings.Join(v, ", ")
 	}
+	return fmt.Sprint(v)
+}
 
+func formatRaindrop(r *types.Raindrop) string {
 	var sb strings.Builder
-	sb.WriteString(fmt.Sprintf("Found %d tags:\n\n", len(tags)))
-
-	for _, t := range tags {
-		sb.WriteString(fmt.Sprintf("- **%s** (%d)\n", t.ID, t.Count))
+	sb.WriteString(fmt.Sprintf("**%s**\n", r.Title))
+	sb.WriteString(fmt.Sprintf("ID: %d\n", r.ID))
+	sb.WriteString(fmt.Sprintf("URL: %s\n", r.Link))
+	if len(r.Tags) > 0 {
+		sb.WriteString(fmt.Sprintf("Tags: %s\n", strings.Join(r.Tags, ", ")))
 	}
-
+	if r.Excerpt != "" {
+		sb.WriteString(fmt.Sprintf("Excerpt: %s\n", r.Excerpt))
+	}
+	if r.Note != "" {
+		sb.WriteString(fmt.Sprintf("Note: %s\n", r.Note))
+	}
+	sb.WriteString(fmt.Sprintf("Created: %s\n", r.Created))
 	return sb.String()
 }
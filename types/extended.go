@@ -90,25 +90,48 @@ type TagWithCount struct {
 	Count int    `json:"count"`
 }
 
-// CreateCollectionRequest is the request for creating a collection
+// CreateCollectionRequest is the request for creating a collection. Nil
+// fields take Raindrop's defaults.
 type CreateCollectionRequest struct {
-	Title  string         `json:"title"`
-	Sort   int            `json:"sort,omitempty"`
-	Public bool           `json:"public,omitempty"`
-	Parent *CollectionRef `json:"parent,omitempty"`
-	View   string         `json:"view,omitempty"`
-	Cover  []string       `json:"cover,omitempty"`
+	Title    string         `json:"title"`
+	Sort     *int           `json:"sort,omitempty"`
+	Public   bool           `json:"public,omitempty"`
+	Parent   *CollectionRef `json:"parent,omitempty"`
+	View     string         `json:"view,omitempty"`
+	Color    string         `json:"color,omitempty"`
+	Cover    []string       `json:"cover,omitempty"`
+	Expanded *bool          `json:"expanded,omitempty"`
 }
 
-// UpdateCollectionRequest is the request for updating a collection
+// UpdateCollectionRequest is the request for updating a collection. Empty
+// and nil fields are left unchanged; an empty Cover list removes the cover.
 type UpdateCollectionRequest struct {
 	Title    string         `json:"title,omitempty"`
-	Sort     int            `json:"sort,omitempty"`
+	Sort     *int           `json:"sort,omitempty"`
 	Public   *bool          `json:"public,omitempty"`
 	Parent   *CollectionRef `json:"parent,omitempty"`
 	View     string         `json:"view,omitempty"`
-	Cover    []string       `json:"cover,omitempty"`
-	Expanded bool           `json:"expanded,omitempty"`
+	Color    string         `json:"color,omitempty"`
+	Cover    *[]string      `json:"cover,omitempty"`
+	Expanded *bool          `json:"expanded,omitempty"`
+}
+
+// CoverGroup is a set of icons from Raindrop's icon library
+type CoverGroup struct {
+	Title string      `json:"title"`
+	Icons []CoverIcon `json:"icons"`
+}
+
+// CoverIcon is an icon that can be used as a collection cover
+type CoverIcon struct {
+	PNG string `json:"png"`
+	SVG string `json:"svg,omitempty"`
+}
+
+// CoversResponse is the response for a cover icon search
+type CoversResponse struct {
+	Result bool         `json:"result"`
+	Items  []CoverGroup `json:"items"`
 }
 
 // SingleCollectionResponse is the response for single collection operations
@@ -30,6 +30,8 @@ type Collection struct {
 	LastUpdate string `json:"lastUpdate,omitempty"`
 	Public     bool   `json:"public,omitempty"`
 	View       string `json:"view,omitempty"`
+	Sort       int    `json:"sort,omitempty"`
+	Expanded   bool   `json:"expanded,omitempty"`
 	Parent     *Parent `json:"parent,omitempty"`
 }
 
@@ -85,18 +87,21 @@ type CreateRaindropRequest struct {
 	Note        string            `json:"note,omitempty"`
 	Tags        []string          `json:"tags,omitempty"`
 	Important   bool              `json:"important,omitempty"`
+	Cover       string            `json:"cover,omitempty"`
 	Collection  *CollectionRef    `json:"collection,omitempty"`
 	PleaseParse map[string]any    `json:"pleaseParse,omitempty"`
 }
 
-// UpdateRaindropRequest is the request body for updating a raindrop
+// UpdateRaindropRequest is the request body for updating a raindrop. Nil
+// fields are left unchanged; empty values clear the field.
 type UpdateRaindropRequest struct {
-	Link       string         `json:"link,omitempty"`
-	Title      string         `json:"title,omitempty"`
-	Excerpt    string         `json:"excerpt,omitempty"`
-	Note       string         `json:"note,omitempty"`
-	Tags       []string       `json:"tags,omitempty"`
+	Link       *string        `json:"link,omitempty"`
+	Title      *string        `json:"title,omitempty"`
+	Excerpt    *string        `json:"excerpt,omitempty"`
+	Note       *string        `json:"note,omitempty"`
+	Tags       *[]string      `json:"tags,omitempty"`
 	Important  *bool          `json:"important,omitempty"`
+	Cover      *string        `json:"cover,omitempty"`
 	Collection *CollectionRef `json:"collection,omitempty"`
 }
 